@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"syscall"
 	"time"
 
 	"github.com/layer5io/meshery/helpers"
@@ -35,6 +36,10 @@ func main() {
 
 	viper.SetDefault("PORT", 8080)
 	viper.SetDefault("ADAPTER_URLS", "")
+	viper.SetDefault("LOAD_TEST_JOB_IMAGE", "layer5/meshery")
+	viper.SetDefault("RESULTS_KEEP_TAGGED", true)
+	viper.SetDefault("RESULTS_JANITOR_INTERVAL", helpers.DefaultResultsJanitorInterval)
+	viper.SetDefault("LOAD_TEST_JOB_RETENTION", helpers.DefaultLoadTestJobRetention)
 
 	home, err := os.UserHomeDir()
 	if viper.GetString("USER_DATA_FOLDER") == "" {
@@ -58,6 +63,34 @@ func main() {
 	}
 	logrus.Infof("Log level: %s", logrus.GetLevel())
 
+	loadGenerators, err := helpers.NewLoadGeneratorRegistry(
+		helpers.NewFortioLoadGenerator(),
+		helpers.NewWRK2LoadGenerator(),
+		helpers.NewNativeLoadGenerator(),
+	)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	switch viper.GetString("MESHERY_ROLE") {
+	case "worker":
+		runLoadTestWorker(ctx, loadGenerators)
+		return
+	case "job":
+		runLoadTestJob(ctx, loadGenerators)
+		return
+	}
+
+	var loadTestCoordinator models.LoadTestCoordinatorInterface
+	if workers := viper.GetStringSlice("LOAD_TEST_WORKERS"); len(workers) > 0 {
+		coordinator, err := helpers.NewLoadTestCoordinator(workers, viper.GetString("LOAD_TEST_WORKER_TOKEN"), viper.GetString("LOAD_TEST_WORKER_CA"))
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		logrus.Infof("Distributing load tests to the workers: %v", coordinator.Workers())
+		loadTestCoordinator = coordinator
+	}
+
 	adapterURLs := viper.GetStringSlice("ADAPTER_URLS")
 
 	adapterTracker := helpers.NewAdaptersTracker(adapterURLs)
@@ -88,6 +121,29 @@ func main() {
 		logrus.Fatal(err)
 	}
 	defer resultPersister.CloseResultPersister()
+	resultsJanitor, err := helpers.NewResultsJanitor(resultPersister, &models.ResultsRetentionPolicy{
+		MaxAge:       viper.GetDuration("RESULTS_MAX_AGE"),
+		MaxCount:     viper.GetInt("RESULTS_MAX_COUNT"),
+		MaxSizeBytes: viper.GetInt64("RESULTS_MAX_SIZE_MB") * 1024 * 1024,
+		KeepTagged:   viper.GetBool("RESULTS_KEEP_TAGGED"),
+	}, viper.GetDuration("RESULTS_JANITOR_INTERVAL"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	jobPersister, err := models.NewBitCaskLoadTestJobPersister(viper.GetString("USER_DATA_FOLDER"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer jobPersister.CloseJobPersister()
+	loadTestJobTracker := helpers.NewLoadTestJobTracker(jobPersister, viper.GetDuration("LOAD_TEST_JOB_RETENTION"))
+
+	schedulePersister, err := models.NewBitCaskLoadTestSchedulePersister(viper.GetString("USER_DATA_FOLDER"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer schedulePersister.CloseSchedulePersister()
+	loadTestScheduler := helpers.NewLoadTestScheduler(schedulePersister, loadTestJobTracker)
 
 	// randID, _ := uuid.NewV4()
 	// cookieSessionStore = sessions.NewCookieStore(randID.Bytes())
@@ -138,6 +194,13 @@ func main() {
 		AdapterTracker: adapterTracker,
 		QueryTracker:   queryTracker,
 
+		LoadTestJobTracker:  loadTestJobTracker,
+		LoadGenerators:      loadGenerators,
+		LoadTestCoordinator: loadTestCoordinator,
+		LoadTestJobImage:    viper.GetString("LOAD_TEST_JOB_IMAGE"),
+		LoadTestScheduler:   loadTestScheduler,
+		ResultsJanitor:      resultsJanitor,
+
 		Queue: mainQueue,
 
 		KubeConfigFolder: viper.GetString("KUBECONFIG_FOLDER"),
@@ -149,6 +212,12 @@ func main() {
 		PrometheusClientForQuery: models.NewPrometheusClientWithHTTPClient(&http.Client{Timeout: time.Second}),
 	})
 
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	go loadTestScheduler.Run(schedulerCtx, h.RunScheduledLoadTest)
+	go resultsJanitor.Run(schedulerCtx)
+	go loadTestJobTracker.Run(schedulerCtx)
+
 	port := viper.GetInt("PORT")
 	r := router.NewRouter(ctx, h, port)
 
@@ -164,3 +233,51 @@ func main() {
 	<-c
 	logrus.Info("Shutting down Meshery")
 }
+
+// runLoadTestWorker runs Meshery as a worker of distributed load tests, it only serves the worker API.
+// A worker listening on other interfaces than the loopback one requires a token, and is served over TLS
+// when LOAD_TEST_WORKER_TLS_CERT and LOAD_TEST_WORKER_TLS_KEY are set.
+func runLoadTestWorker(ctx context.Context, loadGenerators models.LoadGeneratorRegistryInterface) {
+	token := viper.GetString("LOAD_TEST_WORKER_TOKEN")
+	host := viper.GetString("LOAD_TEST_WORKER_HOST")
+	if token == "" && !helpers.IsLoopbackHost(host) {
+		logrus.Fatal("LOAD_TEST_WORKER_TOKEN is required unless the worker only listens on the loopback interface, like with LOAD_TEST_WORKER_HOST=127.0.0.1")
+	}
+	certFile, keyFile := viper.GetString("LOAD_TEST_WORKER_TLS_CERT"), viper.GetString("LOAD_TEST_WORKER_TLS_KEY")
+	if (certFile == "") != (keyFile == "") {
+		logrus.Fatal("LOAD_TEST_WORKER_TLS_CERT and LOAD_TEST_WORKER_TLS_KEY must be set together")
+	}
+	if certFile == "" && !helpers.IsLoopbackHost(host) {
+		logrus.Warn("the worker is served over plain http, set LOAD_TEST_WORKER_TLS_CERT and LOAD_TEST_WORKER_TLS_KEY to serve it over TLS")
+	}
+	h := handlers.NewLoadTestWorkerHandler(loadGenerators, token)
+	port := viper.GetInt("PORT")
+	r := router.NewWorkerRouter(ctx, h, host, port, certFile, keyFile)
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+
+	go func() {
+		logrus.Infof("Starting load test worker listening on %s:%d", host, port)
+		if err := r.Run(); err != nil {
+			logrus.Fatalf("ListenAndServe Error: %v", err)
+		}
+	}()
+	<-c
+	logrus.Info("Shutting down Meshery load test worker")
+}
+
+// runLoadTestJob runs the load test of a Kubernetes Job launched by Meshery, the test is cancelled when the pod is stopped
+func runLoadTestJob(ctx context.Context, loadGenerators models.LoadGeneratorRegistryInterface) {
+	ctx, cancel := context.WithCancel(ctx)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		cancel()
+	}()
+	if err := helpers.RunLoadTestJob(ctx, loadGenerators, os.Getenv, os.Stdout); err != nil {
+		logrus.Fatal(err)
+	}
+	cancel()
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// previousResultsPageSize is the size of the pages read when looking for the previous runs of a test
+	previousResultsPageSize = 100
+	// maxPreviousResultsPages bounds how far back the previous runs of a test are looked for
+	maxPreviousResultsPages = 50
+)
+
+// CompareResultsHandler compares load test results: the first id is the baseline and the other ones are compared to it,
+// with last=N the only id is compared to the N previous runs with the same name and mesh instead
+func (h *Handler) CompareResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, _ *models.Preference, user *models.User, p models.Provider) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	q := req.URL.Query()
+
+	tolerance := models.DefaultRegressionTolerance
+	if t := q.Get("tolerance"); t != "" {
+		var err error
+		tolerance, err = strconv.ParseFloat(t, 64)
+		if err != nil || tolerance < 0 {
+			logrus.Errorf("Error: invalid tolerance: %s", t)
+			http.Error(w, "please provide a valid tolerance", http.StatusBadRequest)
+			return
+		}
+	}
+	last := 0
+	if l := q.Get("last"); l != "" {
+		var err error
+		last, err = strconv.Atoi(l)
+		if err != nil || last < 1 {
+			logrus.Errorf("Error: invalid number of previous runs: %s", l)
+			http.Error(w, "please provide a valid number of previous runs", http.StatusBadRequest)
+			return
+		}
+	}
+	ids := q["id"]
+	if (last == 0 && len(ids) < 2) || (last > 0 && len(ids) != 1) {
+		logrus.Errorf("Error: wrong number of result ids to compare: %d", len(ids))
+		http.Error(w, "please provide at least two result ids, or one with last", http.StatusBadRequest)
+		return
+	}
+
+	results := make([]*models.MesheryResult, len(ids))
+	for i, id := range ids {
+		key := uuid.FromStringOrNil(id)
+		if key == uuid.Nil {
+			logrus.Errorf("Error: invalid id provided to compare results: %s", id)
+			http.Error(w, "please provide valid result ids", http.StatusBadRequest)
+			return
+		}
+		result, err := p.GetResult(req, key)
+		if err != nil {
+			logrus.Errorf("Error: unable to get result %s: %v", id, err)
+			http.Error(w, "error while getting load test results", http.StatusInternalServerError)
+			return
+		}
+		if result.ID == uuid.Nil {
+			result.ID = key
+		}
+		results[i] = result
+	}
+
+	baseline, candidates := results[:1], results[1:]
+	if last > 0 {
+		previous, err := previousResults(req, p, results[0], last)
+		if err != nil {
+			logrus.Errorf("Error: unable to get the previous runs of %s: %v", results[0].Name, err)
+			http.Error(w, "error while getting load test results", http.StatusInternalServerError)
+			return
+		}
+		if len(previous) == 0 {
+			http.Error(w, "no previous run found with the same name and mesh", http.StatusNotFound)
+			return
+		}
+		baseline, candidates = previous, results
+	}
+
+	comparison, err := helpers.CompareResults(baseline, candidates, tolerance)
+	if err != nil {
+		logrus.Errorf("Error: unable to compare results: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(comparison); err != nil {
+		logrus.Errorf("Error: unable to marshal the comparison: %v", err)
+	}
+}
+
+// previousResults returns the n most recent results with the same name and mesh which started before the given one
+func previousResults(req *http.Request, p models.Provider, result *models.MesheryResult, n int) ([]*models.MesheryResult, error) {
+	start := result.StartTime()
+	var previous []*models.MesheryResult
+	for page := 0; page < maxPreviousResultsPages; page++ {
+		bd, err := p.FetchResults(req, strconv.Itoa(page), strconv.Itoa(previousResultsPageSize), result.Name, "")
+		if err != nil {
+			return nil, err
+		}
+		rp := &models.MesheryResultPage{}
+		if err = json.Unmarshal(bd, rp); err != nil {
+			return nil, errors.Wrap(err, "unable to read the results")
+		}
+		for _, r := range rp.Results {
+			if r.ID == result.ID || r.Name != result.Name || r.Mesh != result.Mesh {
+				continue
+			}
+			if !start.IsZero() && !r.StartTime().Before(start) {
+				continue
+			}
+			previous = append(previous, r)
+		}
+		if len(rp.Results) == 0 || (page+1)*previousResultsPageSize >= rp.TotalCount {
+			break
+		}
+	}
+	sort.Slice(previous, func(i, j int) bool {
+		return previous[i].StartTime().After(previous[j].StartTime())
+	})
+	if len(previous) > n {
+		previous = previous[:n]
+	}
+	logrus.Debugf("found %d previous runs of %s", len(previous), result.Name)
+	return previous, nil
+}
@@ -59,10 +59,10 @@ func (h *Handler) EventStreamHandler(w http.ResponseWriter, req *http.Request, _
 	go func() {
 		for mClient := range newAdaptersChan {
 			log.Debug("received a new mesh client, listening for events")
-			go func() {
+			go func(mClient *meshes.MeshClient) {
 				listenForAdapterEvents(req.Context(), mClient, respChan, log)
 				_ = mClient.Close()
-			}()
+			}(mClient)
 		}
 		log.Debug("new adapters channel closed")
 	}()
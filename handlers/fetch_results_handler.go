@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/gofrs/uuid"
 	"github.com/gorilla/sessions"
 	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
@@ -28,6 +30,10 @@ func (h *Handler) FetchResultsHandler(w http.ResponseWriter, req *http.Request,
 	q := req.Form
 
 	bdr, err := p.FetchResults(req, q.Get("page"), q.Get("pageSize"), q.Get("search"), q.Get("order"))
+	if ferr, ok := errors.Cause(err).(*models.ResultsFilterError); ok {
+		http.Error(w, ferr.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "error while getting load test results", http.StatusInternalServerError)
 		return
@@ -36,9 +42,10 @@ func (h *Handler) FetchResultsHandler(w http.ResponseWriter, req *http.Request,
 	_, _ = w.Write(bdr)
 }
 
-// GetResultHandler gets an individual result from provider
-func (h *Handler) GetResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, _ *models.Preference, user *models.User, p models.Provider) {
-	if req.Method != http.MethodGet {
+// ResultHandler gets an individual result from provider on GET, deletes it on DELETE and renames,
+// tags or annotates it on PATCH with a JSON body like {"name": "...", "tags": [...], "notes": "..."}
+func (h *Handler) ResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, _ *models.Preference, user *models.User, p models.Provider) {
+	if req.Method != http.MethodGet && req.Method != http.MethodDelete && req.Method != http.MethodPatch {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -56,9 +63,43 @@ func (h *Handler) GetResultHandler(w http.ResponseWriter, req *http.Request, ses
 		return
 	}
 
+	switch req.Method {
+	case http.MethodDelete:
+		if err := p.DeleteResult(req, key); err != nil {
+			logrus.Errorf("Error: unable to delete result %s: %v", key, err)
+			writeResultError(w, err, "error while deleting load test result")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPatch:
+		update := &models.ResultUpdate{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxLoadTestPayloadSize)).Decode(update); err != nil {
+			logrus.Errorf("Error: unable to parse the result update: %v", err)
+			http.Error(w, "unable to parse the result update", http.StatusBadRequest)
+			return
+		}
+		if err := update.Validate(); err != nil {
+			logrus.Errorf("Error: invalid result update: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err := p.UpdateResult(req, key, update)
+		if err != nil {
+			logrus.Errorf("Error: unable to update result %s: %v", key, err)
+			writeResultError(w, err, "error while updating load test result")
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			logrus.Errorf("Error: unable to marshal result: %v", err)
+		}
+		return
+	}
+
 	bdr, err := p.GetResult(req, key)
 	if err != nil {
-		http.Error(w, "error while getting load test results", http.StatusInternalServerError)
+		writeResultError(w, err, "error while getting load test results")
 		return
 	}
 	sp, err := bdr.ConvertToSpec()
@@ -76,3 +117,12 @@ func (h *Handler) GetResultHandler(w http.ResponseWriter, req *http.Request, ses
 	}
 	_, _ = w.Write(b)
 }
+
+// writeResultError responds with a not found when there is no such result and with the message otherwise
+func writeResultError(w http.ResponseWriter, err error, msg string) {
+	if errors.Cause(err) == models.ErrResultNotFound {
+		http.Error(w, "result not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"sync"
+
 	"github.com/layer5io/meshery/models"
 	"github.com/vmihailenco/taskq"
 )
@@ -9,6 +11,9 @@ import (
 type Handler struct {
 	config *models.HandlerConfig
 	task   *taskq.Task
+
+	// scheduleSessions - cookies of the sessions of the users of the remote provider running scheduled tests
+	scheduleSessions sync.Map
 }
 
 // NewHandlerInstance returns a Handler instance
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxLoadTestCertSize is the largest certificate or key which can be uploaded
+const maxLoadTestCertSize = 1 << 20
+
+// LoadTestCertsHandler is used for persisting the TLS certificates used by the load generators,
+// the private key is never sent back
+func (h *Handler) LoadTestCertsHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, prefObj *models.Preference, user *models.User, provider models.Provider) {
+	switch req.Method {
+	case http.MethodGet:
+		certs := &models.LoadTestCerts{}
+		if prefObj.LoadTestCerts != nil {
+			certs = prefObj.LoadTestCerts.Redacted()
+		}
+		w.Header().Set("content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(certs); err != nil {
+			logrus.Errorf("Error: unable to marshal load test certificates: %v", err)
+			http.Error(w, "unable to retrieve the load test certificates", http.StatusInternalServerError)
+		}
+		return
+	case http.MethodPost:
+		certs, err := readLoadTestCerts(req)
+		if err == nil {
+			err = certs.Validate()
+		}
+		if err != nil {
+			logrus.Errorf("Error: invalid load test certificates: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		certs.UpdatedAt = time.Now()
+		prefObj.LoadTestCerts = certs
+	case http.MethodDelete:
+		prefObj.LoadTestCerts = nil
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := provider.RecordPreferences(req, user.UserID, prefObj); err != nil {
+		logrus.Errorf("unable to save user preferences: %v", err)
+		http.Error(w, "unable to save user preferences", http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write([]byte("{}"))
+}
+
+// readLoadTestCerts reads the PEM encoded cert, key and cacert either from uploaded files or from form values
+func readLoadTestCerts(req *http.Request) (*models.LoadTestCerts, error) {
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") && req.MultipartForm == nil {
+		if err := req.ParseMultipartForm(3 * maxLoadTestCertSize); err != nil {
+			return nil, errors.Wrap(err, "unable to parse the uploaded certificates")
+		}
+	}
+	read := func(field string) (string, error) {
+		if req.MultipartForm != nil {
+			if file, _, err := req.FormFile(field); err == nil {
+				defer func() {
+					_ = file.Close()
+				}()
+				data, err := ioutil.ReadAll(io.LimitReader(file, maxLoadTestCertSize+1))
+				if err != nil {
+					return "", errors.Wrapf(err, "unable to read the %s file", field)
+				}
+				if len(data) > maxLoadTestCertSize {
+					return "", errors.Errorf("the %s file is too large", field)
+				}
+				return string(data), nil
+			}
+		}
+		return req.FormValue(field), nil
+	}
+	certs := &models.LoadTestCerts{}
+	var err error
+	if certs.Cert, err = read("cert"); err != nil {
+		return nil, err
+	}
+	if certs.Key, err = read("key"); err != nil {
+		return nil, err
+	}
+	if certs.CACert, err = read("cacert"); err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+// parseLoadTestCerts sets the TLS certificates of the load test, either uploaded with the test
+// or, when storedCerts is set, the ones saved in the preferences
+func parseLoadTestCerts(req *http.Request, prefObj *models.Preference, opts *models.LoadTestOptions) error {
+	certs, err := readLoadTestCerts(req)
+	if err != nil {
+		return err
+	}
+	if certs.Cert == "" && certs.Key == "" && certs.CACert == "" {
+		useStored := false
+		if storedCerts := req.FormValue("storedCerts"); storedCerts != "" {
+			if useStored, err = strconv.ParseBool(storedCerts); err != nil {
+				return errors.Errorf("invalid value for storedCerts: %s", storedCerts)
+			}
+		}
+		if !useStored {
+			return nil
+		}
+		if prefObj.LoadTestCerts == nil {
+			return errors.New("no certificates have been saved for load tests")
+		}
+		certs = prefObj.LoadTestCerts
+	}
+	if err = certs.Validate(); err != nil {
+		return err
+	}
+	opts.Cert = certs.Cert
+	opts.Key = certs.Key
+	opts.CACert = certs.CACert
+	return nil
+}
@@ -18,8 +18,8 @@ import (
 	"github.com/layer5io/meshery/models"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
 	v1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // LoadTestUsingSMPSHandler runs the load test with the given parameters and SMPS
@@ -39,12 +39,10 @@ func (h *Handler) LoadTestUsingSMPSHandler(w http.ResponseWriter, req *http.Requ
 		http.Error(w, msg, http.StatusInternalServerError)
 		return
 	}
-	benchMark := &models.BenchmarkSpec{}
-	if err := yaml.Unmarshal(body, benchMark); err != nil {
-		msg := "unable to parse the provided input"
-		err = errors.Wrapf(err, msg)
-		logrus.Error(err)
-		http.Error(w, msg, http.StatusBadRequest)
+	loadTestOptions, err := smpsLoadTestOptions(req, prefObj, body)
+	if err != nil {
+		logrus.Errorf("Error: invalid SMPS load test: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	q := req.URL.Query()
@@ -58,60 +56,166 @@ func (h *Handler) LoadTestUsingSMPSHandler(w http.ResponseWriter, req *http.Requ
 	}
 	meshName := q.Get("mesh")
 	testUUID := uuid.Must(uuid.NewV4()).String()
+	loadTestOptions.Name = testName
+
+	h.loadTestHelperHandler(w, req, testName, meshName, testUUID, prefObj, user, loadTestOptions, provider)
+}
+
+// smpsValidation - represents the outcome of validating an SMPS load test without running it
+type smpsValidation struct {
+	Valid  bool                `json:"valid"`
+	Errors []*models.SMPSError `json:"errors,omitempty"`
+}
+
+// ValidateSMPSHandler checks the SMPS document in the body and the query as LoadTestUsingSMPSHandler would,
+// without running the test, the problems are returned with the lines of the document they are found on
+func (h *Handler) ValidateSMPSHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, prefObj *models.Preference, _ *models.User, _ models.Provider) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	defer func() {
+		_ = req.Body.Close()
+	}()
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxLoadTestPayloadSize))
+	if err != nil {
+		logrus.Errorf("Error: unable to read request body: %v", err)
+		http.Error(w, "unable to read request body", http.StatusBadRequest)
+		return
+	}
+	loadTestOptions, err := smpsLoadTestOptions(req, prefObj, body)
+	if err == nil {
+		err = h.validateLoadGenerator(loadTestOptions)
+	}
+	result := &smpsValidation{Valid: err == nil}
+	w.Header().Set("content-type", "application/json")
+	if err != nil {
+		result.Errors = models.SMPSErrors(err)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logrus.Errorf("Error: unable to marshal the validation result: %v", err)
+	}
+}
+
+// smpsLoadTestOptions builds the options of the load test described by the SMPS document and the query,
+// the problems of the document itself are returned as a models.SMPSValidationError
+func smpsLoadTestOptions(req *http.Request, prefObj *models.Preference, body []byte) (*models.LoadTestOptions, error) {
+	benchMark, err := models.ParseBenchmarkSpec(body)
+	if err != nil {
+		return nil, err
+	}
 
 	loadTestOptions := &models.LoadTestOptions{}
 
 	loadTestOptions.Duration = benchMark.EndTime.Sub(benchMark.StartTime)
 
+	if len(benchMark.Stages) > 0 {
+		loadTestOptions.Stages = benchMark.Stages
+		loadTestOptions.Duration = models.TotalDuration(benchMark.Stages)
+	}
+
 	if loadTestOptions.Duration.Seconds() <= 0 {
 		loadTestOptions.Duration = time.Second
 	}
 
-	loadTestOptions.IsGRPC = false
-
 	loadTestOptions.URL = benchMark.EndpointURL
+	if len(benchMark.Endpoints) > 0 {
+		loadTestOptions.Endpoints = benchMark.Endpoints
+		if loadTestOptions.URL == "" {
+			loadTestOptions.URL = benchMark.Endpoints[0].URL
+		}
+	}
 	if benchMark.Client != nil {
 		loadTestOptions.HTTPNumThreads = benchMark.Client.Connections
 		loadTestOptions.HTTPQPS = benchMark.Client.Rps
-
+		loadTestOptions.IsGRPC = strings.EqualFold(benchMark.Client.Protocol, "grpc")
+		// checked with the spec
+		loadTestOptions.Arrival, _ = models.ParseLoadTestArrival(benchMark.Client.Arrival)
+		if g := benchMark.Client.GRPC; g != nil {
+			loadTestOptions.GRPCStreamsCount = g.Streams
+			loadTestOptions.GRPCDoPing = g.Ping
+			loadTestOptions.GRPCPingDelay = g.PingDelay
+			loadTestOptions.GRPCHealthSvc = g.HealthService
+			loadTestOptions.GRPCDoHealth = !g.Ping
+		}
+		if r := benchMark.Client.Request; r != nil {
+			loadTestOptions.Method = strings.ToUpper(r.Method)
+			loadTestOptions.Headers = r.Headers
+			loadTestOptions.ContentType = r.ContentType
+			if r.Body != "" {
+				loadTestOptions.Payload = []byte(r.Body)
+			}
+			loadTestOptions.UserCredentials = r.BasicAuth
+			loadTestOptions.BearerToken = r.BearerToken
+			loadTestOptions.HTTPReqTimeout = r.Timeout
+			loadTestOptions.DisableKeepAlive = r.DisableKeepAlive
+			loadTestOptions.HTTP10 = r.HTTP10
+			loadTestOptions.Compression = r.Compression
+		}
 	}
 
 	if loadTestOptions.HTTPNumThreads < 1 {
 		loadTestOptions.HTTPNumThreads = 1
 	}
 
-	ltURL, err := url.Parse(loadTestOptions.URL)
-	if err != nil || !ltURL.IsAbs() {
-		logrus.Errorf("unable to parse the provided load test url: %v", err)
-		http.Error(w, "invalid load test URL", http.StatusBadRequest)
-		return
+	if err := validateLoadTestOptions(loadTestOptions); err != nil {
+		return nil, err
+	}
+
+	if err := parseLoadTestCerts(req, prefObj, loadTestOptions); err != nil {
+		return nil, errors.Wrap(err, "unable to use the load test certificates")
+	}
+
+	if err := parseInClusterOptions(req, prefObj, loadTestOptions, benchMark.Client != nil && benchMark.Client.Internal); err != nil {
+		return nil, errors.Wrap(err, "unable to run the load test in the cluster")
+	}
+
+	if loadTestOptions.Thresholds, err = parseLoadTestThresholds(req, benchMark.Thresholds); err != nil {
+		return nil, errors.Wrap(err, "invalid load test thresholds")
+	}
+
+	if loadTestOptions.StopConditions, err = parseStopConditions(req, benchMark.StopConditions, loadTestOptions.IsGRPC); err != nil {
+		return nil, errors.Wrap(err, "invalid load test stop conditions")
 	}
-	loadTestOptions.Name = testName
 
 	if loadTestOptions.HTTPQPS < 0 {
 		loadTestOptions.HTTPQPS = 0
 	}
 
-	// loadGenerator := q.Get("loadGenerator")
-
-	// switch loadGenerator {
-	// case "wrk2":
-	// 	loadTestOptions.LoadGenerator = models.Wrk2LG
-	// default:
 	loadTestOptions.LoadGenerator = models.FortioLG
-	// }
+	if loadGenerator := req.URL.Query().Get("loadGenerator"); loadGenerator != "" {
+		loadTestOptions.LoadGenerator = models.LoadGenerator(loadGenerator)
+	}
 
-	h.loadTestHelperHandler(w, req, testName, meshName, testUUID, prefObj, loadTestOptions, provider)
+	return loadTestOptions, nil
 }
 
-// LoadTestHandler runs the load test with the given parameters
+// LoadTestHandler runs the load test with the given parameters or those of a saved profile,
+// a GET without any parameters returns the available load generators and their features
 func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *models.Preference, user *models.User, provider models.Provider) {
 	if req.Method != http.MethodPost && req.Method != http.MethodGet {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if req.Method == http.MethodGet && req.URL.RawQuery == "" {
+		h.loadGeneratorsHandler(w)
+		return
+	}
+
+	// the profile completes the query before it is parsed
+	if err := applyLoadTestProfile(req, prefObj); err != nil {
+		logrus.Errorf("Error: unable to use the load test profile: %v", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 
-	err := req.ParseForm()
+	var err error
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		err = req.ParseMultipartForm(maxLoadTestPayloadSize)
+	} else {
+		err = req.ParseForm()
+	}
 	if err != nil {
 		logrus.Errorf("Error: unable to parse form: %v", err)
 		http.Error(w, "unable to process the received data", http.StatusForbidden)
@@ -140,6 +244,8 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 		dur = "h"
 	case "m":
 		dur = "m"
+	case "ms":
+		dur = "ms"
 	// case "s":
 	default:
 		dur = "s"
@@ -151,7 +257,21 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 		return
 	}
 
-	loadTestOptions.IsGRPC = false
+	if stages := q.Get("stages"); stages != "" {
+		loadTestOptions.Stages, err = models.ParseLoadTestStages(stages)
+		if err != nil {
+			logrus.Errorf("Error: unable to parse load test stages: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		loadTestOptions.Duration = models.TotalDuration(loadTestOptions.Stages)
+	}
+
+	if err = parseGRPCOptions(req, loadTestOptions); err != nil {
+		logrus.Errorf("Error: unable to parse the grpc options: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	cc, _ := strconv.Atoi(q.Get("c"))
 	if cc < 1 {
@@ -160,28 +280,76 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 	loadTestOptions.HTTPNumThreads = cc
 
 	loadTestURL := q.Get("url")
-	ltURL, err := url.Parse(loadTestURL)
-	if err != nil || !ltURL.IsAbs() {
-		logrus.Errorf("unable to parse the provided load test url: %v", err)
-		http.Error(w, "invalid load test URL", http.StatusBadRequest)
-		return
+	if req.Method == http.MethodPost && strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		scenario := &loadTestScenario{}
+		if err := json.NewDecoder(req.Body).Decode(scenario); err != nil {
+			logrus.Errorf("Error: unable to parse the load test scenario: %v", err)
+			http.Error(w, "unable to parse the load test scenario", http.StatusBadRequest)
+			return
+		}
+		if err := validateLoadTestEndpoints(scenario.Endpoints); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		loadTestOptions.Endpoints = scenario.Endpoints
+		if loadTestURL == "" && len(scenario.Endpoints) > 0 {
+			loadTestURL = scenario.Endpoints[0].URL
+		}
 	}
 	loadTestOptions.URL = loadTestURL
+	if err = validateLoadTestOptions(loadTestOptions); err != nil {
+		logrus.Errorf("invalid load test options: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	loadTestOptions.Name = testName
 
+	if err = parseHTTPRequestOptions(req, loadTestOptions); err != nil {
+		logrus.Errorf("Error: unable to parse the http request options: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = parseLoadTestCerts(req, prefObj, loadTestOptions); err != nil {
+		logrus.Errorf("Error: unable to use the load test certificates: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = parseInClusterOptions(req, prefObj, loadTestOptions, false); err != nil {
+		logrus.Errorf("Error: unable to run the load test in the cluster: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if loadTestOptions.Thresholds, err = parseLoadTestThresholds(req, nil); err != nil {
+		logrus.Errorf("Error: invalid load test thresholds: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if loadTestOptions.StopConditions, err = parseStopConditions(req, nil, loadTestOptions.IsGRPC); err != nil {
+		logrus.Errorf("Error: invalid load test stop conditions: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	qps, _ := strconv.ParseFloat(q.Get("qps"), 64)
 	if qps < 0 {
 		qps = 0
 	}
 	loadTestOptions.HTTPQPS = qps
 
-	loadGenerator := q.Get("loadGenerator")
+	if loadTestOptions.Arrival, err = models.ParseLoadTestArrival(req.FormValue("arrival")); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	switch loadGenerator {
-	case models.Wrk2LG.Name():
-		loadTestOptions.LoadGenerator = models.Wrk2LG
-	default:
-		loadTestOptions.LoadGenerator = models.FortioLG
+	loadTestOptions.LoadGenerator = models.FortioLG
+	if loadGenerator := q.Get("loadGenerator"); loadGenerator != "" {
+		loadTestOptions.LoadGenerator = models.LoadGenerator(loadGenerator)
 	}
 
 	// q.Set("json", "on")
@@ -196,11 +364,361 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 	// fortioURL.RawQuery = q.Encode()
 	// logrus.Infof("load test constructed url: %s", fortioURL.String())
 	// fortioResp, err := client.Get(fortioURL.String())
-	h.loadTestHelperHandler(w, req, testName, meshName, testUUID, prefObj, loadTestOptions, provider)
+	h.loadTestHelperHandler(w, req, testName, meshName, testUUID, prefObj, user, loadTestOptions, provider)
+}
+
+// loadGeneratorsHandler writes the capabilities of the registered load generators
+func (h *Handler) loadGeneratorsHandler(w http.ResponseWriter) {
+	gens := h.config.LoadGenerators.List()
+	caps := make([]*models.LoadGeneratorCapabilities, len(gens))
+	for i, gen := range gens {
+		caps[i] = gen.Capabilities()
+	}
+	w.Header().Set("content-type", "application/json")
+	workers := []string{}
+	if h.config.LoadTestCoordinator != nil {
+		workers = h.config.LoadTestCoordinator.Workers()
+	}
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"default":    models.FortioLG.Name(),
+		"generators": caps,
+		"workers":    workers,
+	}); err != nil {
+		logrus.Errorf("Error: unable to marshal the load generators: %v", err)
+		http.Error(w, "unable to retrieve the load generators", http.StatusInternalServerError)
+	}
+}
+
+// maxLoadTestPayloadSize is the largest request body which can be uploaded for a load test
+const maxLoadTestPayloadSize = 10 << 20
+
+// parseHTTPRequestOptions reads the options shaping the requests of the load test,
+// the credentials and the body can also be sent in a form to keep them out of the url
+func parseHTTPRequestOptions(req *http.Request, opts *models.LoadTestOptions) error {
+	opts.Method = strings.ToUpper(req.FormValue("method"))
+	for _, hdr := range req.Form["header"] {
+		kv := strings.SplitN(hdr, ":", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid header '%s', expecting Key: Value", hdr)
+		}
+		if opts.Headers == nil {
+			opts.Headers = map[string]string{}
+		}
+		opts.Headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	opts.ContentType = req.FormValue("contentType")
+	if body := req.FormValue("body"); body != "" {
+		opts.Payload = []byte(body)
+	}
+	if req.MultipartForm != nil {
+		if file, _, err := req.FormFile("payload"); err == nil {
+			defer func() {
+				_ = file.Close()
+			}()
+			if opts.Payload, err = ioutil.ReadAll(file); err != nil {
+				return errors.Wrap(err, "unable to read the payload file")
+			}
+		}
+	}
+	opts.UserCredentials = req.FormValue("user")
+	opts.BearerToken = req.FormValue("bearer")
+	if timeout := req.FormValue("timeout"); timeout != "" {
+		t, err := time.ParseDuration(timeout)
+		if err != nil || t < 0 {
+			return fmt.Errorf("invalid timeout: %s", timeout)
+		}
+		opts.HTTPReqTimeout = t
+	}
+	if keepAlive := req.FormValue("keepAlive"); keepAlive != "" {
+		ka, err := strconv.ParseBool(keepAlive)
+		if err != nil {
+			return fmt.Errorf("invalid value for keepAlive: %s", keepAlive)
+		}
+		opts.DisableKeepAlive = !ka
+	}
+	opts.HTTP10, _ = strconv.ParseBool(req.FormValue("http10"))
+	opts.Compression, _ = strconv.ParseBool(req.FormValue("compression"))
+	return nil
+}
+
+// parseGRPCOptions reads the options of a gRPC load test, which runs a health check
+// unless the fortio ping service is requested
+func parseGRPCOptions(req *http.Request, opts *models.LoadTestOptions) error {
+	opts.IsGRPC, _ = strconv.ParseBool(req.FormValue("grpc"))
+	if !opts.IsGRPC {
+		return nil
+	}
+	if streams := req.FormValue("grpcStreams"); streams != "" {
+		n, err := strconv.Atoi(streams)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid value for grpcStreams: %s", streams)
+		}
+		opts.GRPCStreamsCount = n
+	}
+	opts.GRPCDoPing, _ = strconv.ParseBool(req.FormValue("grpcPing"))
+	if delay := req.FormValue("grpcPingDelay"); delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid value for grpcPingDelay: %s", delay)
+		}
+		opts.GRPCPingDelay = d
+	}
+	opts.GRPCHealthSvc = req.FormValue("grpcHealthSvc")
+	opts.GRPCDoHealth = !opts.GRPCDoPing
+	return nil
+}
+
+// parseInClusterOptions sets where the load test runs when it is run as a Kubernetes Job,
+// which is the case when requested by inCluster or by the caller
+func parseInClusterOptions(req *http.Request, prefObj *models.Preference, opts *models.LoadTestOptions, inCluster bool) error {
+	if v := req.FormValue("inCluster"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid value for inCluster: %s", v)
+		}
+		inCluster = inCluster || b
+	}
+	if !inCluster {
+		return nil
+	}
+	if prefObj.K8SConfig == nil {
+		return errors.New("a Kubernetes cluster has to be configured to run load tests in the cluster")
+	}
+	namespace := req.FormValue("namespace")
+	if namespace != "" {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %s: %s", namespace, strings.Join(errs, ", "))
+		}
+	}
+	sidecar, _ := strconv.ParseBool(req.FormValue("sidecar"))
+	opts.InCluster = &models.InClusterOptions{
+		Namespace:     namespace,
+		InjectSidecar: sidecar,
+	}
+	return nil
+}
+
+// parseLoadTestThresholds overrides the given thresholds with the ones of the query,
+// latencies are in milliseconds and the error rate in percent
+func parseLoadTestThresholds(req *http.Request, thresholds *models.LoadTestThresholds) (*models.LoadTestThresholds, error) {
+	t := &models.LoadTestThresholds{}
+	if thresholds != nil {
+		*t = *thresholds
+	}
+	for param, field := range map[string]*float64{
+		"p50Ms":           &t.P50Ms,
+		"p90Ms":           &t.P90Ms,
+		"p99Ms":           &t.P99Ms,
+		"maxErrorPercent": &t.MaxErrorPercent,
+		"minQps":          &t.MinQPS,
+	} {
+		v := req.FormValue(param)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", param, v)
+		}
+		*field = f
+	}
+	if t.IsEmpty() {
+		return nil, nil
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseStopConditions overrides the given stop conditions with the ones of the query: abortOn takes a comma
+// separated list of status codes, abortWindow and abortCheckInterval take durations
+func parseStopConditions(req *http.Request, conds *models.LoadTestStopConditions, isGRPC bool) (*models.LoadTestStopConditions, error) {
+	c := &models.LoadTestStopConditions{}
+	if conds != nil {
+		*c = *conds
+	}
+	if v := req.FormValue("abortOn"); v != "" {
+		c.StatusCodes = nil
+		for _, s := range strings.Split(v, ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code to stop on: %s", s)
+			}
+			c.StatusCodes = append(c.StatusCodes, code)
+		}
+	}
+	for param, field := range map[string]*float64{
+		"abortOnErrorPercent":      &c.MaxErrorPercent,
+		"abortOnLatencyMs":         &c.MaxLatencyMs,
+		"abortOnLatencyPercentile": &c.LatencyPercentile,
+	} {
+		v := req.FormValue(param)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", param, v)
+		}
+		*field = f
+	}
+	for param, field := range map[string]*time.Duration{
+		"abortWindow":        &c.Window,
+		"abortCheckInterval": &c.CheckInterval,
+	} {
+		v := req.FormValue(param)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", param, v)
+		}
+		*field = d
+	}
+	if c.IsEmpty() {
+		return nil, nil
+	}
+	if isGRPC {
+		return nil, errors.New("stop conditions are only supported for HTTP load tests")
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validateLoadTestOptions checks the target of the test and the combinations of options which are not supported
+func validateLoadTestOptions(opts *models.LoadTestOptions) error {
+	if opts.IsGRPC {
+		// gRPC destinations are host:port, optionally prefixed by http:// or https://
+		if strings.TrimSpace(opts.URL) == "" || strings.ContainsAny(opts.URL, " \t") {
+			return errors.New("invalid gRPC destination")
+		}
+		if len(opts.Endpoints) > 0 || len(opts.Stages) > 0 {
+			return errors.New("scenarios and staged profiles are only supported for HTTP load tests at the moment")
+		}
+		return nil
+	}
+	ltURL, err := url.Parse(opts.URL)
+	if err != nil || !ltURL.IsAbs() {
+		return errors.New("invalid load test URL")
+	}
+	return nil
+}
+
+// loadTestScenario - represents the body of a multi-endpoint load test request
+type loadTestScenario struct {
+	Endpoints []*models.LoadTestEndpoint `json:"endpoints,omitempty"`
+}
+
+func validateLoadTestEndpoints(endpoints []*models.LoadTestEndpoint) error {
+	for _, ep := range endpoints {
+		if ep == nil {
+			return errors.New("invalid endpoint in scenario")
+		}
+		if err := ep.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLoadGenerator checks that the load generator of the test is available and supports the options
+func (h *Handler) validateLoadGenerator(loadTestOptions *models.LoadTestOptions) error {
+	gen, err := h.config.LoadGenerators.Get(loadTestOptions.LoadGenerator)
+	if err == nil {
+		err = gen.Validate(loadTestOptions)
+	}
+	if err == nil && h.config.LoadTestCoordinator != nil && loadTestOptions.IsGRPC && loadTestOptions.InCluster == nil {
+		err = errors.New("distributed load tests only support HTTP at the moment")
+	}
+	return err
 }
 
 func (h *Handler) loadTestHelperHandler(w http.ResponseWriter, req *http.Request, testName, meshName, testUUID string,
+	prefObj *models.Preference, user *models.User, loadTestOptions *models.LoadTestOptions, provider models.Provider) {
+	log := logrus.WithField("file", "load_test_handler")
+
+	if err := h.validateLoadGenerator(loadTestOptions); err != nil {
+		log.Errorf("invalid load test options: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobID, _ := uuid.NewV4()
+	job := &models.LoadTestJob{
+		ID:            jobID,
+		Name:          testName,
+		Mesh:          meshName,
+		TestUUID:      testUUID,
+		URL:           loadTestOptions.URL,
+		LoadGenerator: loadTestOptions.LoadGenerator.Name(),
+		UserID:        user.UserID,
+		Provider:      provider.Name(),
+		Status:        models.LoadTestJobQueued,
+	}
+	// the job must outlive the request, so it is not derived from the request context
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := h.config.LoadTestJobTracker.AddJob(ctx, job, cancel); err != nil {
+		cancel()
+		log.Errorf("unable to create a load test job: %v", err)
+		http.Error(w, "error while running load test", http.StatusInternalServerError)
+		return
+	}
+	go h.runLoadTestJob(ctx, cancel, req, jobID, testName, meshName, testUUID, prefObj, loadTestOptions, provider)
+
+	if async, _ := strconv.ParseBool(req.URL.Query().Get("async")); async {
+		jobCopy, err := h.config.LoadTestJobTracker.GetJob(ctx, jobID)
+		if err != nil {
+			log.Errorf("unable to retrieve the load test job: %v", err)
+			http.Error(w, "error while running load test", http.StatusInternalServerError)
+			return
+		}
+		jobCopy.Messages = nil
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(jobCopy); err != nil {
+			log.Errorf("unable to marshal the load test job: %v", err)
+		}
+		return
+	}
+	h.streamLoadTestJob(w, req, jobID)
+}
+
+// runLoadTestJob executes the load test and records its progress on the job tracker
+func (h *Handler) runLoadTestJob(ctx context.Context, cancel context.CancelFunc, req *http.Request, jobID uuid.UUID, testName, meshName, testUUID string,
 	prefObj *models.Preference, loadTestOptions *models.LoadTestOptions, provider models.Provider) {
+	defer cancel()
+	respChan := make(chan *models.LoadTestResponse, 100)
+	go func() {
+		h.executeLoadTest(ctx, req, testName, meshName, testUUID, prefObj, provider, loadTestOptions, respChan)
+		close(respChan)
+	}()
+
+	status := models.LoadTestJobFailed
+	var resultID string
+	for data := range respChan {
+		h.config.LoadTestJobTracker.PublishMessage(context.Background(), jobID, data)
+		switch data.Status {
+		case models.LoadTestSuccess:
+			status = models.LoadTestJobCompleted
+			if data.Result != nil {
+				resultID = data.Result.ID.String()
+			}
+		case models.LoadTestError:
+			status = models.LoadTestJobFailed
+		}
+	}
+	if status != models.LoadTestJobCompleted && ctx.Err() != nil {
+		status = models.LoadTestJobCancelled
+	}
+	h.config.LoadTestJobTracker.UpdateJobStatus(context.Background(), jobID, status, resultID)
+}
+
+// streamLoadTestJob replays the messages of the job and streams the upcoming ones as server sent events
+func (h *Handler) streamLoadTestJob(w http.ResponseWriter, req *http.Request, jobID uuid.UUID) {
 	log := logrus.WithField("file", "load_test_handler")
 
 	flusher, ok := w.(http.Flusher)
@@ -209,54 +727,94 @@ func (h *Handler) loadTestHelperHandler(w http.ResponseWriter, req *http.Request
 		http.Error(w, "Event streaming is not supported at the moment.", http.StatusInternalServerError)
 		return
 	}
+
+	history, sub, unsubscribe, err := h.config.LoadTestJobTracker.Subscribe(req.Context(), jobID)
+	if err != nil {
+		log.Errorf("unable to subscribe to the load test job: %v", err)
+		http.Error(w, "please provide a valid job id", http.StatusNotFound)
+		return
+	}
+	defer unsubscribe()
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	notify := w.(http.CloseNotifier).CloseNotify()
-	respChan := make(chan *models.LoadTestResponse, 100)
-	endChan := make(chan struct{})
-	defer close(endChan)
+	writeData := func(data *models.LoadTestResponse) bool {
+		bd, err := json.Marshal(data)
+		if err != nil {
+			log.Errorf("error: unable to marshal meshery result for shipping: %v", err)
+			return false
+		}
+		log.Debug("received new data on response channel")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", bd)
+		flusher.Flush()
+		log.Debugf("Flushed the messages on the wire...")
+		return true
+	}
 
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Errorf("Recovered from panic: %v.", r)
+	for _, data := range history {
+		if !writeData(data) {
+			return
+		}
+	}
+
+	notify := w.(http.CloseNotifier).CloseNotify()
+	for {
+		select {
+		case <-notify:
+			log.Debugf("received signal to close connection, the load test job continues in the background")
+			return
+		case data, ok := <-sub:
+			if !ok {
+				log.Debugf("load test completed")
+				return
 			}
-		}()
-		for data := range respChan {
-			bd, err := json.Marshal(data)
-			if err != nil {
-				logrus.Errorf("error: unable to marshal meshery result for shipping: %v", err)
-				http.Error(w, "error while running load test", http.StatusInternalServerError)
+			if !writeData(data) {
 				return
 			}
+		}
+	}
+}
 
-			log.Debug("received new data on response channel")
-			_, _ = fmt.Fprintf(w, "data: %s\n\n", bd)
-			if flusher != nil {
-				flusher.Flush()
-				log.Debugf("Flushed the messages on the wire...")
+// streamLoadTestProgress sends the live metrics of the recorder every second until the returned func is called,
+// only the elapsed time is sent when partial is set
+func streamLoadTestProgress(recorder *helpers.LoadTestRecorder, partial bool, respChan chan *models.LoadTestResponse) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(helpers.SnapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				snapshot := recorder.Snapshot()
+				if partial {
+					snapshot = &models.LoadTestSnapshot{
+						Time:            snapshot.Time,
+						ElapsedSeconds:  snapshot.ElapsedSeconds,
+						DurationSeconds: snapshot.DurationSeconds,
+						Partial:         true,
+					}
+				}
+				respChan <- &models.LoadTestResponse{
+					Status:   models.LoadTestProgress,
+					Snapshot: snapshot,
+				}
 			}
 		}
-		endChan <- struct{}{}
-		log.Debug("response channel closed")
-	}()
-	go func() {
-		h.executeLoadTest(req, testName, meshName, testUUID, prefObj, provider, loadTestOptions, respChan)
-		close(respChan)
 	}()
-	select {
-	case <-notify:
-		log.Debugf("received signal to close connection and channels")
-		break
-	case <-endChan:
-		log.Debugf("load test completed")
+	return func() {
+		close(done)
+		<-stopped
 	}
 }
 
-func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUID string, prefObj *models.Preference, provider models.Provider, loadTestOptions *models.LoadTestOptions, respChan chan *models.LoadTestResponse) {
+func (h *Handler) executeLoadTest(ctx context.Context, req *http.Request, testName, meshName, testUUID string, prefObj *models.Preference, provider models.Provider, loadTestOptions *models.LoadTestOptions, respChan chan *models.LoadTestResponse) {
 	respChan <- &models.LoadTestResponse{
 		Status:  models.LoadTestInfo,
 		Message: "Initiating load test . . . ",
@@ -267,13 +825,48 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 		resultInst *periodic.RunnerResults
 		err        error
 	)
-	if loadTestOptions.LoadGenerator == models.Wrk2LG {
-		resultsMap, resultInst, err = helpers.WRK2LoadTest(loadTestOptions)
+	gen, err := h.config.LoadGenerators.Get(loadTestOptions.LoadGenerator)
+	if err != nil {
+		logrus.Error(err)
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestError,
+			Message: "error: unable to perform load test",
+		}
+		return
+	}
+	duration := loadTestOptions.Duration
+	if len(loadTestOptions.Stages) > 0 {
+		duration = models.TotalDuration(loadTestOptions.Stages)
+	}
+	recorder := helpers.NewLoadTestRecorder(duration)
+	// only fortio over http and the native generator report their requests as they complete,
+	// the Kubernetes Jobs report the metrics of the generator they run
+	partial := (loadTestOptions.InCluster == nil && h.config.LoadTestCoordinator != nil) || loadTestOptions.IsGRPC ||
+		(loadTestOptions.LoadGenerator != models.FortioLG && loadTestOptions.LoadGenerator != models.NativeLG)
+	stopProgress := streamLoadTestProgress(recorder, partial, respChan)
+	ctx = helpers.WithLoadTestRecorder(ctx, recorder)
+	if loadTestOptions.InCluster != nil {
+		var runner *helpers.KubernetesJobRunner
+		runner, err = helpers.NewKubernetesJobRunnerForConfig(prefObj.K8SConfig.Config, prefObj.K8SConfig.ContextName, h.config.LoadTestJobImage)
+		if err == nil {
+			resultsMap, resultInst, err = runner.Run(ctx, loadTestOptions, func(msg string) {
+				respChan <- &models.LoadTestResponse{
+					Status:  models.LoadTestInfo,
+					Message: msg,
+				}
+			})
+		}
+	} else if h.config.LoadTestCoordinator != nil {
+		resultsMap, resultInst, err = h.config.LoadTestCoordinator.Run(ctx, loadTestOptions)
 	} else {
-		resultsMap, resultInst, err = helpers.FortioLoadTest(loadTestOptions)
+		resultsMap, resultInst, err = helpers.RunLoadTest(ctx, gen, loadTestOptions)
 	}
+	stopProgress()
 	if err != nil {
 		msg := "error: unable to perform load test"
+		if ctx.Err() != nil {
+			msg = "load test was cancelled"
+		}
 		err = errors.Wrap(err, msg)
 		logrus.Error(err)
 		respChan <- &models.LoadTestResponse{
@@ -283,11 +876,16 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 		return
 	}
 
+	msg := "Load test completed, fetching metadata now"
+	if reason := (&models.MesheryResult{Result: resultsMap}).AbortReason(); reason != "" {
+		msg = fmt.Sprintf("Load test was stopped early as %s, fetching metadata now", reason)
+	}
 	respChan <- &models.LoadTestResponse{
 		Status:  models.LoadTestInfo,
-		Message: "Load test completed, fetching metadata now",
+		Message: msg,
 	}
 
+	var detectedMeshes map[string][]v1.Deployment
 	if prefObj.K8SConfig != nil {
 		nodesChan := make(chan []*models.K8SNode)
 		versionChan := make(chan string)
@@ -339,10 +937,18 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 				"nodes":          prefObj.K8SConfig.Nodes,
 			}
 		}
-		installedMeshes := <-installedMeshesChan
-		if len(installedMeshes) > 0 {
-			resultsMap["detected-meshes"] = installedMeshes
+		detectedMeshes = <-installedMeshesChan
+		if len(detectedMeshes) > 0 {
+			resultsMap["detected-meshes"] = detectedMeshes
 		}
+		if b := helpers.InspectMesh(prefObj.K8SConfig.Config, prefObj.K8SConfig.ContextName, meshName, detectedMeshes); b != nil {
+			resultsMap["mesh_build"] = b.MeshBuild
+			resultsMap["proxy_build"] = b.ProxyBuild
+			resultsMap["mesh_config"] = b.Config
+		}
+	}
+	if prefObj.Prometheus != nil && prefObj.Prometheus.PrometheusURL != "" && resultInst != nil {
+		h.collectMeshMetrics(ctx, prefObj.Prometheus.PrometheusURL, meshName, detectedMeshes, resultInst, resultsMap, respChan)
 	}
 	respChan <- &models.LoadTestResponse{
 		Status:  models.LoadTestInfo,
@@ -363,6 +969,18 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 		Result: resultsMap,
 	}
 
+	if !loadTestOptions.Thresholds.IsEmpty() {
+		result.Verdict, err = loadTestOptions.Thresholds.Evaluate(resultsMap)
+		if err != nil {
+			logrus.Warnf("unable to check the load test thresholds: %v", err)
+		} else {
+			respChan <- &models.LoadTestResponse{
+				Status:  models.LoadTestInfo,
+				Message: "Load test " + result.Verdict.String(),
+			}
+		}
+	}
+
 	resultID, err := provider.PublishResults(req, result)
 	if err != nil {
 		// http.Error(w, "error while getting load test results", http.StatusInternalServerError)
@@ -411,8 +1029,40 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 	result.ID = key
 	// w.Write(bd)
 	respChan <- &models.LoadTestResponse{
-		Status: models.LoadTestSuccess,
-		Result: result,
+		Status:  models.LoadTestSuccess,
+		Result:  result,
+		Verdict: result.Verdict,
+	}
+}
+
+// meshMetricsTimeout is how long the metrics of the service mesh are collected for at most after a test
+const meshMetricsTimeout = 30 * time.Second
+
+// collectMeshMetrics adds the SMPS metrics of the service mesh over the test window to the results,
+// a test is not failed because of them
+func (h *Handler) collectMeshMetrics(ctx context.Context, promURL, meshName string, detectedMeshes map[string][]v1.Deployment,
+	resultInst *periodic.RunnerResults, resultsMap map[string]interface{}, respChan chan *models.LoadTestResponse) {
+	mesh := helpers.MeshForMetrics(meshName, detectedMeshes)
+	if mesh == "" {
+		logrus.Debugf("not collecting the service mesh metrics as the mesh of the test is unknown")
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, meshMetricsTimeout)
+	defer cancel()
+	start := resultInst.StartTime
+	metrics, err := helpers.CollectMeshMetrics(ctx, h.config.PrometheusClient, promURL, mesh, start, start.Add(resultInst.ActualDuration))
+	if err != nil {
+		logrus.Warnf("unable to collect the service mesh metrics: %v", err)
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: fmt.Sprintf("Unable to collect the metrics of %s from Prometheus", mesh),
+		}
+		return
+	}
+	resultsMap["metrics"] = metrics
+	respChan <- &models.LoadTestResponse{
+		Status:  models.LoadTestInfo,
+		Message: fmt.Sprintf("Collected the metrics of %s from Prometheus", mesh),
 	}
 }
 
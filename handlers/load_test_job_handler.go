@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadTestJobsHandler lists the load test jobs of the user, optionally filtered by status
+func (h *Handler) LoadTestJobsHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, user *models.User, provider models.Provider) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	status := models.LoadTestJobStatus(req.URL.Query().Get("status"))
+	jobs, err := h.config.LoadTestJobTracker.GetJobs(req.Context(), status)
+	if err != nil {
+		logrus.Errorf("Error: unable to retrieve load test jobs: %v", err)
+		http.Error(w, "unable to retrieve load test jobs", http.StatusInternalServerError)
+		return
+	}
+	owned := []*models.LoadTestJob{}
+	for _, job := range jobs {
+		if !job.OwnedBy(user.UserID, provider.Name()) {
+			continue
+		}
+		// messages can be fetched per job, they carry the complete results
+		job.Messages = nil
+		owned = append(owned, job)
+	}
+	sort.Slice(owned, func(i, j int) bool {
+		return owned[i].CreatedAt.After(owned[j].CreatedAt)
+	})
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(owned); err != nil {
+		logrus.Errorf("Error: unable to marshal load test jobs: %v", err)
+		http.Error(w, "unable to retrieve load test jobs", http.StatusInternalServerError)
+	}
+}
+
+// LoadTestJobHandler returns the status of a load test job of the user on GET and cancels it on DELETE
+func (h *Handler) LoadTestJobHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, user *models.User, provider models.Provider) {
+	if req.Method != http.MethodGet && req.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	job, ok := h.ownedLoadTestJob(w, req, user, provider)
+	if !ok {
+		return
+	}
+
+	if req.Method == http.MethodDelete {
+		if err := h.config.LoadTestJobTracker.CancelJob(req.Context(), job.ID); err != nil {
+			logrus.Errorf("Error: unable to cancel load test job: %v", err)
+			http.Error(w, "unable to cancel the load test job", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		logrus.Errorf("Error: unable to marshal load test job: %v", err)
+		http.Error(w, "unable to retrieve the load test job", http.StatusInternalServerError)
+	}
+}
+
+// LoadTestJobStreamHandler reattaches to a load test job of the user and streams its progress
+func (h *Handler) LoadTestJobStreamHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, user *models.User, provider models.Provider) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	job, ok := h.ownedLoadTestJob(w, req, user, provider)
+	if !ok {
+		return
+	}
+	h.streamLoadTestJob(w, req, job.ID)
+}
+
+// ownedLoadTestJob retrieves the job of the request, the jobs of the other users are reported as not found
+func (h *Handler) ownedLoadTestJob(w http.ResponseWriter, req *http.Request, user *models.User, provider models.Provider) (*models.LoadTestJob, bool) {
+	jobID, ok := parseLoadTestJobID(w, req)
+	if !ok {
+		return nil, false
+	}
+	job, err := h.config.LoadTestJobTracker.GetJob(req.Context(), jobID)
+	if err == nil && !job.OwnedBy(user.UserID, provider.Name()) {
+		err = fmt.Errorf("job with id %s not found", jobID)
+	}
+	if err != nil {
+		logrus.Errorf("Error: unable to retrieve load test job: %v", err)
+		http.Error(w, "please provide a valid job id", http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
+
+func parseLoadTestJobID(w http.ResponseWriter, req *http.Request) (uuid.UUID, bool) {
+	id := req.URL.Query().Get("id")
+	if id == "" {
+		logrus.Errorf("Error: no id provided for the load test job")
+		http.Error(w, "please provide a job id", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	jobID := uuid.FromStringOrNil(id)
+	if jobID == uuid.Nil {
+		logrus.Errorf("Error: invalid id provided for the load test job")
+		http.Error(w, "please provide a valid job id", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return jobID, true
+}
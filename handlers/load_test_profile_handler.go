@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadTestProfilesHandler lists the saved load test profiles on GET and saves a new one on POST
+func (h *Handler) LoadTestProfilesHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, prefObj *models.Preference, user *models.User, provider models.Provider) {
+	switch req.Method {
+	case http.MethodGet:
+		profiles := prefObj.LoadTestProfiles
+		if profiles == nil {
+			profiles = []*models.LoadTestProfile{}
+		}
+		writeLoadTestProfile(w, profiles, http.StatusOK)
+	case http.MethodPost:
+		profile, err := h.readLoadTestProfile(req)
+		if err != nil {
+			logrus.Errorf("Error: invalid load test profile: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		profile.ID, _ = uuid.NewV4()
+		profile.CreatedAt = time.Now()
+		profile.UpdatedAt = profile.CreatedAt
+		prefObj.LoadTestProfiles = append(prefObj.LoadTestProfiles, profile)
+		if err := provider.RecordPreferences(req, user.UserID, prefObj); err != nil {
+			logrus.Errorf("unable to save user preferences: %v", err)
+			http.Error(w, "unable to save user preferences", http.StatusInternalServerError)
+			return
+		}
+		writeLoadTestProfile(w, profile, http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// LoadTestProfileHandler returns a saved load test profile on GET, replaces it on PUT and deletes it on DELETE
+func (h *Handler) LoadTestProfileHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, prefObj *models.Preference, user *models.User, provider models.Provider) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPut && req.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := uuid.FromStringOrNil(req.URL.Query().Get("id"))
+	existing := prefObj.GetLoadTestProfile(id)
+	if existing == nil {
+		logrus.Errorf("Error: no load test profile found with id %q", req.URL.Query().Get("id"))
+		http.Error(w, "please provide a valid profile id", http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeLoadTestProfile(w, existing, http.StatusOK)
+		return
+	case http.MethodPut:
+		profile, err := h.readLoadTestProfile(req)
+		if err != nil {
+			logrus.Errorf("Error: invalid load test profile: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		profile.ID = existing.ID
+		profile.CreatedAt = existing.CreatedAt
+		profile.UpdatedAt = time.Now()
+		*existing = *profile
+	case http.MethodDelete:
+		profiles := []*models.LoadTestProfile{}
+		for _, p := range prefObj.LoadTestProfiles {
+			if p.ID != id {
+				profiles = append(profiles, p)
+			}
+		}
+		prefObj.LoadTestProfiles = profiles
+	}
+	if err := provider.RecordPreferences(req, user.UserID, prefObj); err != nil {
+		logrus.Errorf("unable to save user preferences: %v", err)
+		http.Error(w, "unable to save user preferences", http.StatusInternalServerError)
+		return
+	}
+	if req.Method == http.MethodDelete {
+		_, _ = w.Write([]byte("{}"))
+		return
+	}
+	writeLoadTestProfile(w, existing, http.StatusOK)
+}
+
+// readLoadTestProfile reads and validates the profile in the JSON body of the request
+func (h *Handler) readLoadTestProfile(req *http.Request) (*models.LoadTestProfile, error) {
+	defer func() {
+		_ = req.Body.Close()
+	}()
+	profile := &models.LoadTestProfile{}
+	if err := json.NewDecoder(req.Body).Decode(profile); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the load test profile")
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	if profile.LoadGenerator != "" {
+		if _, err := h.config.LoadGenerators.Get(models.LoadGenerator(profile.LoadGenerator)); err != nil {
+			return nil, fmt.Errorf("invalid load generator: %s", profile.LoadGenerator)
+		}
+	}
+	return profile, nil
+}
+
+func writeLoadTestProfile(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logrus.Errorf("Error: unable to marshal load test profiles: %v", err)
+	}
+}
+
+// applyLoadTestProfile completes the query of a load test request with the settings of the profile given
+// by the profile param, the params of the request take precedence over the ones of the profile
+func applyLoadTestProfile(req *http.Request, prefObj *models.Preference) error {
+	q := req.URL.Query()
+	profileID := q.Get("profile")
+	if profileID == "" {
+		return nil
+	}
+	profile := prefObj.GetLoadTestProfile(uuid.FromStringOrNil(profileID))
+	if profile == nil {
+		return fmt.Errorf("no load test profile found with id %s", profileID)
+	}
+	setDefault := func(param, value string) {
+		if value != "" && q.Get(param) == "" {
+			q.Set(param, value)
+		}
+	}
+	formatFloat := func(f float64) string {
+		if f == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	setDefault("name", profile.Name)
+	setDefault("mesh", profile.Mesh)
+	setDefault("url", profile.URL)
+	setDefault("loadGenerator", profile.LoadGenerator)
+	if profile.ConcurrentRequests > 0 {
+		setDefault("c", strconv.Itoa(profile.ConcurrentRequests))
+	}
+	setDefault("qps", formatFloat(profile.QueriesPerSecond))
+	if d, err := time.ParseDuration(profile.Duration); err == nil && q.Get("t") == "" {
+		// in milliseconds to keep the durations which are not whole seconds
+		q.Set("t", strconv.FormatInt(int64(d/time.Millisecond), 10))
+		q.Set("dur", "ms")
+	}
+	if t := profile.Thresholds; t != nil {
+		setDefault("p50Ms", formatFloat(t.P50Ms))
+		setDefault("p90Ms", formatFloat(t.P90Ms))
+		setDefault("p99Ms", formatFloat(t.P99Ms))
+		setDefault("maxErrorPercent", formatFloat(t.MaxErrorPercent))
+		setDefault("minQps", formatFloat(t.MinQPS))
+	}
+	// the headers of the request are added to the ones of the profile, overriding those with the same name
+	given := map[string]bool{}
+	for _, hdr := range q["header"] {
+		if kv := strings.SplitN(hdr, ":", 2); len(kv) == 2 {
+			given[http.CanonicalHeaderKey(strings.TrimSpace(kv[0]))] = true
+		}
+	}
+	for k, v := range profile.Headers {
+		if !given[http.CanonicalHeaderKey(k)] {
+			q.Add("header", k+": "+v)
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+	return nil
+}
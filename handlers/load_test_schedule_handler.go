@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// loadTestScheduleRequest - represents the body of a request creating a schedule
+type loadTestScheduleRequest struct {
+	Name string                     `json:"name,omitempty"`
+	Cron string                     `json:"cron"`
+	Test *models.LoadTestDefinition `json:"test"`
+}
+
+// LoadTestSchedulesHandler lists the load test schedules of the user on GET and creates one on POST
+func (h *Handler) LoadTestSchedulesHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, user *models.User, provider models.Provider) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	h.keepScheduleSession(req, user, provider)
+	if req.Method == http.MethodPost {
+		h.addLoadTestSchedule(w, req, user, provider)
+		return
+	}
+	all, err := h.config.LoadTestScheduler.GetSchedules(req.Context())
+	if err != nil {
+		logrus.Errorf("Error: unable to retrieve load test schedules: %v", err)
+		http.Error(w, "unable to retrieve load test schedules", http.StatusInternalServerError)
+		return
+	}
+	schedules := []*models.LoadTestSchedule{}
+	for _, schedule := range all {
+		if !schedule.OwnedBy(user.UserID, provider.Name()) {
+			continue
+		}
+		// the history can be fetched per schedule
+		schedule.Runs = nil
+		schedules = append(schedules, schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.After(schedules[j].CreatedAt)
+	})
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(schedules); err != nil {
+		logrus.Errorf("Error: unable to marshal load test schedules: %v", err)
+		http.Error(w, "unable to retrieve load test schedules", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) addLoadTestSchedule(w http.ResponseWriter, req *http.Request, user *models.User, provider models.Provider) {
+	defer func() {
+		_ = req.Body.Close()
+	}()
+	sr := &loadTestScheduleRequest{}
+	if err := json.NewDecoder(req.Body).Decode(sr); err != nil {
+		logrus.Errorf("Error: unable to parse the load test schedule: %v", err)
+		http.Error(w, "unable to parse the load test schedule", http.StatusBadRequest)
+		return
+	}
+	if _, err := helpers.ParseCronSchedule(sr.Cron); err != nil {
+		logrus.Errorf("Error: invalid load test schedule: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateLoadTestDefinition(sr.Test); err != nil {
+		logrus.Errorf("Error: invalid load test definition: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, _ := uuid.NewV4()
+	schedule := &models.LoadTestSchedule{
+		ID:       id,
+		Name:     sr.Name,
+		Cron:     sr.Cron,
+		Test:     sr.Test,
+		UserID:   user.UserID,
+		Provider: provider.Name(),
+	}
+	if schedule.Name == "" {
+		q, _ := url.ParseQuery(sr.Test.Query)
+		schedule.Name = q.Get("name")
+	}
+	if err := h.config.LoadTestScheduler.AddSchedule(req.Context(), schedule); err != nil {
+		logrus.Errorf("Error: unable to create the load test schedule: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeLoadTestSchedule(w, req, id, http.StatusCreated)
+}
+
+// validateLoadTestDefinition checks what the load test APIs need to accept the definition later on
+func validateLoadTestDefinition(def *models.LoadTestDefinition) error {
+	if def == nil {
+		return errors.New("please provide the load test to schedule")
+	}
+	q, err := url.ParseQuery(def.Query)
+	if err != nil {
+		return errors.Wrap(err, "invalid load test query")
+	}
+	if q.Get("name") == "" {
+		return errors.New("please provide a name for the test")
+	}
+	if def.SMPS && strings.TrimSpace(def.Body) == "" {
+		return errors.New("please provide the benchmark spec of the test")
+	}
+	return nil
+}
+
+// LoadTestScheduleHandler returns a schedule of the user along with its run history on GET,
+// pauses or resumes it on PUT as per the paused query param and deletes it on DELETE
+func (h *Handler) LoadTestScheduleHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, user *models.User, provider models.Provider) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPut && req.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := uuid.FromStringOrNil(req.URL.Query().Get("id"))
+	if id == uuid.Nil {
+		logrus.Errorf("Error: invalid id provided for the load test schedule")
+		http.Error(w, "please provide a valid schedule id", http.StatusBadRequest)
+		return
+	}
+	h.keepScheduleSession(req, user, provider)
+	schedule, err := h.config.LoadTestScheduler.GetSchedule(req.Context(), id)
+	if err != nil || !schedule.OwnedBy(user.UserID, provider.Name()) {
+		// the schedules of other users are not disclosed
+		logrus.Errorf("Error: load test schedule %s not found for the user", id)
+		http.Error(w, "please provide a valid schedule id", http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodDelete:
+		if err := h.config.LoadTestScheduler.DeleteSchedule(req.Context(), id); err != nil {
+			logrus.Errorf("Error: unable to delete load test schedule: %v", err)
+			http.Error(w, "unable to delete the load test schedule", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+		return
+	case http.MethodPut:
+		paused, err := strconv.ParseBool(req.URL.Query().Get("paused"))
+		if err != nil {
+			logrus.Errorf("Error: invalid paused value for the load test schedule: %v", err)
+			http.Error(w, "please provide whether the schedule is paused", http.StatusBadRequest)
+			return
+		}
+		if !paused && schedule.UserID == "" {
+			http.Error(w, "the schedule was created before schedules had an owner, please create it again", http.StatusBadRequest)
+			return
+		}
+		if err := h.config.LoadTestScheduler.PauseSchedule(req.Context(), id, paused); err != nil {
+			logrus.Errorf("Error: unable to update load test schedule: %v", err)
+			http.Error(w, "unable to update the load test schedule", http.StatusNotFound)
+			return
+		}
+	}
+	h.writeLoadTestSchedule(w, req, id, http.StatusOK)
+}
+
+func (h *Handler) writeLoadTestSchedule(w http.ResponseWriter, req *http.Request, id uuid.UUID, status int) {
+	schedule, err := h.config.LoadTestScheduler.GetSchedule(req.Context(), id)
+	if err != nil {
+		logrus.Errorf("Error: unable to retrieve load test schedule: %v", err)
+		http.Error(w, "please provide a valid schedule id", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(schedule); err != nil {
+		logrus.Errorf("Error: unable to marshal load test schedule: %v", err)
+	}
+}
+
+// keepScheduleSession keeps the cookies of the session of a user of the remote provider in memory, so that
+// the scheduled tests of the user can be attributed to it, the session is not persisted
+func (h *Handler) keepScheduleSession(req *http.Request, user *models.User, provider models.Provider) {
+	if provider.GetProviderType() != models.RemoteProviderType || user == nil || user.UserID == "" {
+		return
+	}
+	h.scheduleSessions.Store(provider.Name()+"/"+user.UserID, req.Cookies())
+}
+
+// scheduleSession returns the session the scheduled test runs in, the remote provider needs the session of
+// the owner which is known from the last time the owner used the schedule APIs since Meshery started
+func (h *Handler) scheduleSession(req *http.Request, schedule *models.LoadTestSchedule, provider models.Provider) (*sessions.Session, error) {
+	if provider.GetProviderType() == models.RemoteProviderType {
+		key := schedule.Provider + "/" + schedule.UserID
+		cookies, ok := h.scheduleSessions.Load(key)
+		if !ok {
+			return nil, errors.New("the session of the owner of the schedule is not known since Meshery started, the runs resume once the owner opens the schedules again")
+		}
+		for _, c := range cookies.([]*http.Cookie) {
+			req.AddCookie(c)
+		}
+		if user, err := provider.GetUserDetails(req); err != nil || user == nil || user.UserID != schedule.UserID {
+			h.scheduleSessions.Delete(key)
+			return nil, errors.New("the session of the owner of the schedule has expired, the runs resume once the owner opens the schedules again")
+		}
+	}
+	return provider.GetSession(req)
+}
+
+// RunScheduledLoadTest submits the test of the schedule to the load test APIs on behalf of its owner,
+// as an asynchronous test, and returns the id of its job
+func (h *Handler) RunScheduledLoadTest(ctx context.Context, schedule *models.LoadTestSchedule) (uuid.UUID, error) {
+	if schedule.UserID == "" {
+		return uuid.Nil, errors.New("the schedule was created before schedules had an owner, please create it again")
+	}
+	provider, ok := h.config.Providers[schedule.Provider]
+	if !ok {
+		return uuid.Nil, fmt.Errorf("the provider %s of the schedule is not available", schedule.Provider)
+	}
+	path, next := "/api/load-test", h.LoadTestHandler
+	if schedule.Test.SMPS {
+		path, next = "/api/load-test-smps", h.LoadTestUsingSMPSHandler
+	}
+	q, err := url.ParseQuery(schedule.Test.Query)
+	if err != nil {
+		return uuid.Nil, errors.Wrap(err, "invalid load test query")
+	}
+	q.Set("async", "true")
+	method := http.MethodGet
+	if schedule.Test.Body != "" {
+		method = http.MethodPost
+	}
+	// the job outlives this call, so the request is not tied to the context
+	req, err := http.NewRequest(method, path+"?"+q.Encode(), strings.NewReader(schedule.Test.Body))
+	if err != nil {
+		return uuid.Nil, errors.Wrap(err, "unable to create the load test request")
+	}
+	if schedule.Test.ContentType != "" {
+		req.Header.Set("Content-Type", schedule.Test.ContentType)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), models.ProviderCtxKey, provider))
+	session, err := h.scheduleSession(req, schedule, provider)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	prefObj, err := provider.ReadFromPersister(schedule.UserID)
+	if err != nil || prefObj == nil {
+		prefObj = &models.Preference{
+			AnonymousUsageStats:  true,
+			AnonymousPerfResults: true,
+		}
+	}
+
+	w := &bufferedResponseWriter{header: http.Header{}, status: http.StatusOK}
+	next(w, req, session, prefObj, &models.User{UserID: schedule.UserID}, provider)
+	if w.status != http.StatusAccepted {
+		return uuid.Nil, fmt.Errorf("the load test was not started, status %d: %s", w.status, strings.TrimSpace(w.body.String()))
+	}
+	job := &models.LoadTestJob{}
+	if err := json.Unmarshal(w.body.Bytes(), job); err != nil {
+		return uuid.Nil, errors.Wrap(err, "unable to parse the load test job")
+	}
+	return job.ID, nil
+}
+
+// bufferedResponseWriter keeps the response of a handler called in process
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	b.wrote = true
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if !b.wrote {
+		b.status = status
+		b.wrote = true
+	}
+}
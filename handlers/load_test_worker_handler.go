@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadTestWorkerHandler runs the shares of distributed load tests sent by a coordinator
+type LoadTestWorkerHandler struct {
+	loadGenerators models.LoadGeneratorRegistryInterface
+	token          string
+}
+
+// NewLoadTestWorkerHandler creates a new instance of LoadTestWorkerHandler, requests have to carry
+// the token when it is not empty and can only come from the loopback interface otherwise
+func NewLoadTestWorkerHandler(loadGenerators models.LoadGeneratorRegistryInterface, token string) *LoadTestWorkerHandler {
+	return &LoadTestWorkerHandler{
+		loadGenerators: loadGenerators,
+		token:          token,
+	}
+}
+
+// PingHandler reports that the worker is up
+func (h *LoadTestWorkerHandler) PingHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write([]byte("{}"))
+}
+
+// RunHandler waits for the start time of the load test and runs it, the test is cancelled
+// when the coordinator goes away
+func (h *LoadTestWorkerHandler) RunHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if h.token == "" {
+		if host, _, err := net.SplitHostPort(req.RemoteAddr); err != nil || !helpers.IsLoopbackHost(host) {
+			writeWorkerResponse(w, http.StatusUnauthorized, &models.WorkerLoadTestResponse{Error: "a worker token is required for the coordinators on other hosts"})
+			return
+		}
+	} else if subtle.ConstantTimeCompare([]byte(req.Header.Get(models.WorkerTokenHeader)), []byte(h.token)) != 1 {
+		writeWorkerResponse(w, http.StatusUnauthorized, &models.WorkerLoadTestResponse{Error: "invalid worker token"})
+		return
+	}
+	workerReq := &models.WorkerLoadTestRequest{}
+	if err := json.NewDecoder(req.Body).Decode(workerReq); err != nil || workerReq.Options == nil {
+		logrus.Errorf("Error: unable to parse the worker load test request: %v", err)
+		writeWorkerResponse(w, http.StatusBadRequest, &models.WorkerLoadTestResponse{Error: "unable to parse the load test request"})
+		return
+	}
+	opts := workerReq.Options
+	gen, err := h.loadGenerators.Get(opts.LoadGenerator)
+	if err == nil {
+		err = gen.Validate(opts)
+	}
+	if err != nil {
+		logrus.Errorf("Error: invalid load test options: %v", err)
+		writeWorkerResponse(w, http.StatusBadRequest, &models.WorkerLoadTestResponse{Error: err.Error()})
+		return
+	}
+
+	if wait := time.Until(workerReq.StartAt); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	} else {
+		logrus.Warnf("load test %s started %v after the coordinated start time", opts.Name, -wait)
+	}
+
+	logrus.Infof("running load test %s on this worker", opts.Name)
+	resultsMap, _, err := helpers.RunLoadTest(req.Context(), gen, opts)
+	if err != nil {
+		logrus.Errorf("Error: unable to perform load test: %v", err)
+		writeWorkerResponse(w, http.StatusInternalServerError, &models.WorkerLoadTestResponse{Error: err.Error()})
+		return
+	}
+	writeWorkerResponse(w, http.StatusOK, &models.WorkerLoadTestResponse{Result: resultsMap})
+}
+
+func writeWorkerResponse(w http.ResponseWriter, status int, resp *models.WorkerLoadTestResponse) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Errorf("Error: unable to marshal the worker response: %v", err)
+	}
+}
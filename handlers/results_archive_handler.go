@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportResultsHandler sends an archive of all the results of the provider, a gzipped tarball with a manifest
+// and the results with their server metrics as JSON lines
+func (h *Handler) ExportResultsHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, _ *models.User, provider models.Provider) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("content-type", "application/gzip")
+	w.Header().Set("content-disposition", fmt.Sprintf(`attachment; filename="meshery-results-%s.tar.gz"`, time.Now().Format("20060102-150405")))
+	manifest, err := provider.ExportResults(req, w)
+	if err != nil {
+		// the archive is only written once the results were all read, an error while writing it leaves it truncated
+		w.Header().Del("content-disposition")
+		logrus.Errorf("Error: unable to export the results: %v", err)
+		if err == models.ErrResultsArchiveNotSupported {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		http.Error(w, "unable to export the results", http.StatusInternalServerError)
+		return
+	}
+	logrus.Infof("exported %d results", manifest.Results)
+}
+
+// ImportResultsHandler imports the results of an archive made by ExportResultsHandler, the results already
+// stored being skipped
+func (h *Handler) ImportResultsHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, _ *models.User, provider models.Provider) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	defer func() {
+		_ = req.Body.Close()
+	}()
+	summary, err := provider.ImportResults(req, req.Body)
+	if err != nil {
+		logrus.Errorf("Error: unable to import the results: %v", err)
+		if err == models.ErrResultsArchiveNotSupported {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if summary != nil {
+			http.Error(w, fmt.Sprintf("%v, %d results were imported", err, summary.Imported), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logrus.Infof("imported %d results, skipped %d already stored and %d invalid", summary.Imported, summary.Skipped, summary.Invalid)
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		logrus.Errorf("Error: unable to marshal the import summary: %v", err)
+	}
+}
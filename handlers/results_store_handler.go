@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ResultsStoreHandler reports the size of the local results store, its last compaction and the retention policy
+// on GET and enforces the retention policy right away on POST, which deletes the results of every user and is
+// therefore only accepted from the loopback interface
+func (h *Handler) ResultsStoreHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, _ *models.User, _ models.Provider) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if h.config.ResultsJanitor == nil {
+		http.Error(w, "results are not stored locally", http.StatusNotImplemented)
+		return
+	}
+	if req.Method == http.MethodPost {
+		if host, _, err := net.SplitHostPort(req.RemoteAddr); err != nil || !helpers.IsLoopbackHost(host) {
+			logrus.Errorf("Error: the results retention can only be enforced from the loopback interface, request from %s", req.RemoteAddr)
+			http.Error(w, "the results retention can only be enforced from the host running Meshery", http.StatusForbidden)
+			return
+		}
+		if _, err := h.config.ResultsJanitor.RunOnce(req.Context()); err != nil {
+			logrus.Errorf("Error: unable to enforce the results retention: %v", err)
+			http.Error(w, "unable to enforce the results retention", http.StatusInternalServerError)
+			return
+		}
+	}
+	status, err := h.config.ResultsJanitor.Status(req.Context())
+	if err != nil {
+		logrus.Errorf("Error: unable to retrieve the status of the results store: %v", err)
+		http.Error(w, "unable to retrieve the status of the results store", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logrus.Errorf("Error: unable to marshal the status of the results store: %v", err)
+		http.Error(w, "unable to retrieve the status of the results store", http.StatusInternalServerError)
+	}
+}
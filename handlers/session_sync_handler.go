@@ -71,6 +71,13 @@ func (h *Handler) SessionSyncHandler(w http.ResponseWriter, req *http.Request, _
 		}
 	}
 
+	if prefObj.LoadTestCerts != nil {
+		// the private key is never sent back, working on a copy as the preferences are cached
+		displayPref := *prefObj
+		displayPref.LoadTestCerts = prefObj.LoadTestCerts.Redacted()
+		prefObj = &displayPref
+	}
+
 	err = json.NewEncoder(w).Encode(prefObj)
 	if err != nil {
 		logrus.Errorf("error marshalling user config data: %v", err)
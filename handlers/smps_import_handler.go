@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// importedResult - represents a result created from an imported SMPS document
+type importedResult struct {
+	ID   string `json:"meshery_id"`
+	Name string `json:"name,omitempty"`
+}
+
+// ImportSMPSResultsHandler imports the results of the SMPS documents in the body as Meshery results,
+// the body is a YAML stream of documents which are either a spec or a list of specs
+func (h *Handler) ImportSMPSResultsHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, _ *models.User, provider models.Provider) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	defer func() {
+		_ = req.Body.Close()
+	}()
+	specs, err := readBenchmarkSpecs(http.MaxBytesReader(w, req.Body, maxLoadTestPayloadSize))
+	if err != nil {
+		logrus.Errorf("Error: unable to parse the SMPS documents: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(specs) == 0 {
+		http.Error(w, "please provide at least one SMPS document", http.StatusBadRequest)
+		return
+	}
+
+	// nothing is imported unless all the documents are valid
+	q := req.URL.Query()
+	results := make([]*models.MesheryResult, len(specs))
+	for i, spec := range specs {
+		result, err := spec.ConvertToMesheryResult()
+		if err != nil {
+			logrus.Errorf("Error: invalid SMPS document %d: %v", i+1, err)
+			http.Error(w, fmt.Sprintf("invalid SMPS document %d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		if name := q.Get("name"); name != "" {
+			result.Name = name
+		}
+		if result.Name == "" {
+			result.Name = "imported " + spec.StartTime.Format("2006-01-02 15:04:05")
+		}
+		result.Mesh = q.Get("mesh")
+		results[i] = result
+	}
+
+	imported := []*importedResult{}
+	for i, result := range results {
+		resultID, err := provider.ImportResult(req, result)
+		if err == nil && resultID == "" {
+			err = errors.New("the provider did not return an id for the result")
+		}
+		if err != nil {
+			logrus.Errorf("Error: unable to persist the result of SMPS document %d: %v", i+1, err)
+			http.Error(w, fmt.Sprintf("unable to persist the result of SMPS document %d, %d were imported: %v", i+1, len(imported), err), http.StatusInternalServerError)
+			return
+		}
+		imported = append(imported, &importedResult{
+			ID:   resultID,
+			Name: result.Name,
+		})
+	}
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(imported); err != nil {
+		logrus.Errorf("Error: unable to marshal the imported results: %v", err)
+	}
+}
+
+// readBenchmarkSpecs reads the specs of a YAML stream, JSON documents being YAML as well
+func readBenchmarkSpecs(r io.Reader) ([]*models.BenchmarkSpec, error) {
+	specs := []*models.BenchmarkSpec{}
+	dec := yaml.NewDecoder(r)
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return specs, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse the provided input")
+		}
+		items := []interface{}{doc}
+		if list, ok := doc.([]interface{}); ok {
+			items = list
+		}
+		for _, item := range items {
+			// empty documents are left out
+			if item == nil {
+				continue
+			}
+			bd, err := yaml.Marshal(item)
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to parse the provided input")
+			}
+			spec := &models.BenchmarkSpec{}
+			if err = yaml.Unmarshal(bd, spec); err != nil {
+				return nil, errors.Wrapf(err, "unable to parse SMPS document %d", len(specs)+1)
+			}
+			specs = append(specs, spec)
+		}
+	}
+}
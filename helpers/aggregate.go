@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// toHTTPRunnerResults converts a results map back to the fortio results it was built from
+func toHTTPRunnerResults(resultsMap map[string]interface{}) (*fhttp.HTTPRunnerResults, error) {
+	bd, err := json.Marshal(resultsMap)
+	if err != nil {
+		return nil, err
+	}
+	result := &fhttp.HTTPRunnerResults{}
+	if err = json.Unmarshal(bd, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// toResultsMap converts fortio compatible results to the map persisted in MesheryResult
+func toResultsMap(res periodic.HasRunnerResult) (map[string]interface{}, error) {
+	bd, err := json.Marshal(res)
+	if err != nil {
+		err = errors.Wrap(err, "error while converting results to map")
+		logrus.Error(err)
+		return nil, err
+	}
+	resultsMap := map[string]interface{}{}
+	if err = json.Unmarshal(bd, &resultsMap); err != nil {
+		err = errors.Wrap(err, "error while unmarshaling data to map")
+		logrus.Error(err)
+		return nil, err
+	}
+	return resultsMap, nil
+}
+
+// aggregateHTTPResults combines several results as if they were a single run.
+// Sequential results follow each other in time, the others ran side by side.
+func aggregateHTTPResults(labels, url string, results []*fhttp.HTTPRunnerResults, sequential bool) *fhttp.HTTPRunnerResults {
+	agg := &fhttp.HTTPRunnerResults{
+		RunnerResults: periodic.RunnerResults{
+			RunType: "HTTP",
+			Labels:  labels,
+		},
+		RetCodes: map[int]int64{},
+		URL:      url,
+	}
+	durations := []*stats.HistogramData{}
+	sizes := []*stats.HistogramData{}
+	headerSizes := []*stats.HistogramData{}
+	var (
+		requestedQPS float64
+		endTime      time.Time
+	)
+	for _, r := range results {
+		if agg.StartTime.IsZero() || r.StartTime.Before(agg.StartTime) {
+			agg.StartTime = r.StartTime
+		}
+		if end := r.StartTime.Add(r.ActualDuration); end.After(endTime) {
+			endTime = end
+		}
+		agg.Version = r.Version
+		agg.SocketCount += r.SocketCount
+		if sequential {
+			if r.NumThreads > agg.NumThreads {
+				agg.NumThreads = r.NumThreads
+			}
+		} else {
+			agg.NumThreads += r.NumThreads
+			agg.RequestedDuration = r.RequestedDuration
+		}
+		if qps, err := strconv.ParseFloat(r.RequestedQPS, 64); err == nil {
+			requestedQPS += qps
+		}
+		for code, count := range r.RetCodes {
+			agg.RetCodes[code] += count
+		}
+		durations = append(durations, r.DurationHistogram)
+		sizes = append(sizes, r.Sizes)
+		headerSizes = append(headerSizes, r.HeaderSizes)
+	}
+	agg.ActualDuration = endTime.Sub(agg.StartTime)
+	if sequential {
+		agg.RequestedDuration = agg.ActualDuration.String()
+		agg.RequestedQPS = "staged"
+	} else if requestedQPS > 0 {
+		agg.RequestedQPS = strconv.FormatFloat(requestedQPS, 'g', -1, 64)
+	} else {
+		agg.RequestedQPS = "max"
+	}
+	agg.DurationHistogram = MergeHistogramData(defaultPercentiles, durations...)
+	agg.Sizes = MergeHistogramData(defaultPercentiles, sizes...)
+	agg.HeaderSizes = MergeHistogramData(defaultPercentiles, headerSizes...)
+	if agg.ActualDuration > 0 {
+		agg.ActualQPS = float64(agg.DurationHistogram.Count) / agg.ActualDuration.Seconds()
+	}
+	if agg.StartTime.IsZero() {
+		agg.StartTime = time.Now()
+	}
+	return agg
+}
@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ClientTLSLoadTest runs the load test through a local reverse proxy which verifies the service using
+// the CA certificates of the test and presents its client certificate, as neither fortio nor wrk2
+// can do mutual TLS on their own. The time spent in the proxy is part of the measured latencies.
+func ClientTLSLoadTest(ctx context.Context, opts *models.LoadTestOptions, runFunc LoadTestFunc) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if opts.IsGRPC {
+		if opts.Cert != "" {
+			err := errors.New("client certificates are not supported for gRPC load tests at the moment")
+			logrus.Error(err)
+			return nil, nil, err
+		}
+		// fortio verifies gRPC services with the CA certificate on its own
+		return runFunc(ctx, opts)
+	}
+
+	target, err := url.Parse(strings.TrimSpace(opts.URL))
+	if err != nil || target.Scheme != "https" {
+		err = errors.New("TLS certificates can only be used with https URLs")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	tlsConfig, err := clientTLSConfig(opts)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	tlsConfig.ServerName = target.Hostname()
+
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: target.Scheme, Host: target.Host})
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		// services are routed by host, which has to be the one of the tested URL
+		req.Host = target.Host
+	}
+	proxy.Transport = &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConns:        opts.HTTPNumThreads,
+		MaxIdleConnsPerHost: opts.HTTPNumThreads,
+		DisableKeepAlives:   opts.DisableKeepAlive,
+		// compression is negotiated by the load generator
+		DisableCompression: true,
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		err = errors.Wrap(err, "unable to start the TLS proxy")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	srv := &http.Server{Handler: proxy}
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	defer func() {
+		_ = srv.Close()
+	}()
+
+	proxyOpts := *opts
+	proxyOpts.URL = "http://" + listener.Addr().String() + target.RequestURI()
+	logrus.Debugf("running the load test for %s through the TLS proxy at %s", opts.URL, proxyOpts.URL)
+	resultsMap, result, err := runFunc(ctx, &proxyOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the results are reported for the tested URL rather than for the proxy
+	labels := opts.Name + " -_- " + opts.URL
+	resultsMap["URL"] = opts.URL
+	resultsMap["Labels"] = labels
+	if result != nil {
+		result.Labels = labels
+	}
+	return resultsMap, result, nil
+}
+
+// clientTLSConfig builds the TLS config presenting the client certificate of the test
+func clientTLSConfig(opts *models.LoadTestOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.IsInsecure, // nolint: gosec
+	}
+	if opts.Cert != "" || opts.Key != "" {
+		cert, err := tls.X509KeyPair([]byte(opts.Cert), []byte(opts.Key))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid client certificate or key")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if opts.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(opts.CACert)) {
+			return nil, errors.New("invalid CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
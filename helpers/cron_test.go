@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	// 2020-01-01 is a Wednesday
+	tests := []struct {
+		expr, from, next string
+	}{
+		{"*/15 * * * *", "2020-01-01 10:07:30", "2020-01-01 10:15:00"},
+		{"*/15 * * * *", "2020-01-01 10:45:00", "2020-01-01 11:00:00"},
+		{"10-20/5 * * * *", "2020-01-01 10:20:00", "2020-01-01 11:10:00"},
+		{"0 1-5 * * *", "2020-01-01 05:00:00", "2020-01-02 01:00:00"},
+		{"0 9 * * 1-5", "2020-01-03 09:00:00", "2020-01-06 09:00:00"},
+		{"0 12 * * MON-FRI", "2020-01-04 08:00:00", "2020-01-06 12:00:00"},
+		{"30 8 * * mon,WED,Fri", "2020-01-01 09:00:00", "2020-01-03 08:30:00"},
+		{"0 0 * * 7", "2020-01-01 00:00:00", "2020-01-05 00:00:00"},
+		{"0 0 1,15 * *", "2020-01-02 00:00:00", "2020-01-15 00:00:00"},
+		{"0 0 1 */3 *", "2020-02-15 00:00:00", "2020-04-01 00:00:00"},
+		{"0 0 1 jan-mar *", "2020-03-01 00:00:00", "2021-01-01 00:00:00"},
+		{"0 0 29 2 *", "2020-03-01 00:00:00", "2024-02-29 00:00:00"},
+		// a day matches either the day of month or the day of week when both are restricted
+		{"0 0 13 * 5", "2020-01-01 00:00:00", "2020-01-03 00:00:00"},
+		{"0 0 13 * 5", "2020-01-11 00:00:00", "2020-01-13 00:00:00"},
+		// but both have to match when one of them is not restricted
+		{"0 0 */2 * 5", "2020-01-01 00:00:00", "2020-01-03 00:00:00"},
+		{"0 0 */2 * 5", "2020-01-03 00:00:00", "2020-01-17 00:00:00"},
+		{"@hourly", "2020-01-01 10:30:00", "2020-01-01 11:00:00"},
+		{"@weekly", "2020-01-01 10:30:00", "2020-01-05 00:00:00"},
+		{"@yearly", "2020-01-01 10:30:00", "2021-01-01 00:00:00"},
+	}
+	for _, tt := range tests {
+		c, err := ParseCronSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if next := c.Next(at(tt.from)); !next.Equal(at(tt.next)) {
+			t.Errorf("%q from %s: expected %s, got %s", tt.expr, tt.from, tt.next, next.Format("2006-01-02 15:04:05"))
+		}
+	}
+}
+
+func TestParseCronScheduleInvalid(t *testing.T) {
+	tests := []struct {
+		expr, err string
+	}{
+		{"0 0 31 2 *", "never fires"},
+		{"0 0 30 2 *", "never fires"},
+		{"* * * *", "expected 5 fields"},
+		{"* * * * * *", "expected 5 fields"},
+		{"", "expected 5 fields"},
+		{"@reboot", "expected 5 fields"},
+		{"60 * * * *", "minute field"},
+		{"* 24 * * *", "hour field"},
+		{"* * 0 * *", "day of month field"},
+		{"* * 32 * *", "day of month field"},
+		{"* * * 13 *", "month field"},
+		{"* * * foo *", "month field"},
+		{"* * * * 8", "day of week field"},
+		{"* * * * MON-FOO", "day of week field"},
+		{"*/0 * * * *", "invalid step"},
+		{"*/x * * * *", "invalid step"},
+		{"5-1 * * * *", "invalid range"},
+		{"FRI-MON * * * *", "minute field"},
+		{"1,,2 * * * *", "minute field"},
+		{"-1 * * * *", "minute field"},
+	}
+	for _, tt := range tests {
+		_, err := ParseCronSchedule(tt.expr)
+		if err == nil {
+			t.Errorf("%q: expected an error", tt.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: expected an error about %q, got %v", tt.expr, tt.err, err)
+		}
+	}
+}
@@ -1,47 +1,87 @@
 package helpers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 
 	"fortio.org/fortio/fgrpc"
 	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/fnet"
 	"fortio.org/fortio/periodic"
 	"github.com/layer5io/meshery/models"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultPercentiles are the percentiles computed for every fortio compatible run
+var defaultPercentiles = []float64{50, 75, 90, 99, 99.9}
+
 // SharedHTTPOptions is the flag->httpoptions transfer code shared between
 // fortio_main and fcurl.
-func sharedHTTPOptions(opts *models.LoadTestOptions) *fhttp.HTTPOptions {
+func sharedHTTPOptions(opts *models.LoadTestOptions) (*fhttp.HTTPOptions, error) {
 	url := strings.TrimLeft(opts.URL, " \t\r\n")
 	httpOpts := fhttp.HTTPOptions{}
 	httpOpts.URL = url
-	httpOpts.HTTP10 = false
+	httpOpts.HTTP10 = opts.HTTP10
 	httpOpts.DisableFastClient = false
-	httpOpts.DisableKeepAlive = false
+	httpOpts.DisableKeepAlive = opts.DisableKeepAlive
 	httpOpts.AllowHalfClose = false
-	httpOpts.Compression = false
+	httpOpts.Compression = opts.Compression
 	httpOpts.HTTPReqTimeOut = fhttp.HTTPReqTimeOutDefaultValue
+	if opts.HTTPReqTimeout > 0 {
+		httpOpts.HTTPReqTimeOut = opts.HTTPReqTimeout
+	}
 	httpOpts.Insecure = opts.IsInsecure
-	httpOpts.UserCredentials = ""
-	httpOpts.ContentType = ""
-	// httpOpts.Payload = fnet.GeneratePayload(*PayloadFileFlag, *PayloadSizeFlag, *PayloadFlag)
+	httpOpts.UserCredentials = opts.UserCredentials
+	httpOpts.ContentType = opts.ContentType
+	httpOpts.Payload = opts.Payload
 	// httpOpts.UnixDomainSocket = *unixDomainSocketFlag
 	// if false { // *followRedirectsFlag {
 	httpOpts.FollowRedirects = true
 	httpOpts.DisableFastClient = true
 	// }
-	return &httpOpts
+	for k, v := range opts.Headers {
+		if err := httpOpts.AddAndValidateExtraHeader(k + ":" + v); err != nil {
+			return nil, err
+		}
+	}
+	if opts.BearerToken != "" {
+		if err := httpOpts.AddAndValidateExtraHeader("Authorization: Bearer " + opts.BearerToken); err != nil {
+			return nil, err
+		}
+	}
+	switch strings.ToUpper(opts.Method) {
+	case "":
+	case fnet.GET:
+		if len(httpOpts.Payload) > 0 || httpOpts.ContentType != "" {
+			return nil, errors.New("fortio can only send a request body with the POST method")
+		}
+	case fnet.POST:
+		// fortio switches to POST when a content type is set
+		if httpOpts.ContentType == "" {
+			httpOpts.ContentType = "application/octet-stream"
+		}
+	default:
+		return nil, fmt.Errorf("fortio does not support the %s method", opts.Method)
+	}
+	return &httpOpts, nil
 }
 
-// FortioLoadTest is the actual code which invokes Fortio to run the load test
-func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+// FortioLoadTest is the actual code which invokes Fortio to run the load test,
+// the run is aborted when the given context is cancelled
+func FortioLoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
 	defaults := &periodic.DefaultRunnerOptions
 	// httpOpts := bincommon.SharedHTTPOptions()
-	httpOpts := sharedHTTPOptions(opts)
+	httpOpts, err := sharedHTTPOptions(opts)
+	if err != nil {
+		err = errors.Wrap(err, "error while building http options")
+		logrus.Error(err)
+		return nil, nil, err
+	}
 	if opts.IsInsecure {
 		httpOpts.Insecure = true
 	}
@@ -84,19 +124,40 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 		QPS:         qps,
 		Duration:    opts.Duration,
 		NumThreads:  opts.HTTPNumThreads,
-		Percentiles: []float64{50, 75, 90, 99, 99.9},
+		Percentiles: defaultPercentiles,
 		Resolution:  defaults.Resolution,
 		Out:         out,
 		Labels:      labels,
 		Exactly:     0,
+		Stop:        periodic.NewAborter(),
 	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ro.Stop.Abort()
+		case <-done:
+		}
+	}()
 	var res periodic.HasRunnerResult
-	var err error
 	if opts.IsGRPC {
+		var caCertFile string
+		caCertFile, err = writeCACertFile(opts.CACert)
+		if err != nil {
+			err = errors.Wrap(err, "error while preparing the CA certificate")
+			logrus.Error(err)
+			return nil, nil, err
+		}
+		if caCertFile != "" {
+			defer func() {
+				_ = os.Remove(caCertFile)
+			}()
+		}
 		o := fgrpc.GRPCRunnerOptions{
 			RunnerOptions:      ro,
 			Destination:        rURL,
-			CACert:             opts.CACert,
+			CACert:             caCertFile,
 			Service:            opts.GRPCHealthSvc,
 			Streams:            opts.GRPCStreamsCount,
 			AllowInitialErrors: opts.AllowInitialErrors,
@@ -114,7 +175,10 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 			AllowInitialErrors: opts.AllowInitialErrors,
 			AbortOn:            0,
 		}
-		res, err = fhttp.RunHTTPTest(&o)
+		res, err = runFortioHTTPTest(&o, loadTestRecorderFrom(ctx))
+	}
+	if err == nil {
+		err = loadTestCanceled(ctx)
 	}
 	if err != nil {
 		err = errors.Wrap(err, "error while running tests")
@@ -150,3 +214,23 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
 	return resultsMap, result, nil
 }
+
+// writeCACertFile writes the PEM encoded CA certificate to a temporary file as fortio loads it from disk,
+// the returned path is empty when there is no certificate
+func writeCACertFile(caCert string) (string, error) {
+	if caCert == "" {
+		return "", nil
+	}
+	f, err := ioutil.TempFile("", "meshery-ca-*.pem")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err = f.WriteString(caCert); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,113 @@
+package helpers
+
+// runFortioHTTPTest is adapted from RunHTTPTest of fortio.org/fortio/fhttp
+// (Copyright 2017 Istio Authors, Apache License 2.0) which offers no way to observe the requests as they complete.
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/sirupsen/logrus"
+)
+
+// fortioHTTPRunnable is the state of a fortio thread, it reports each request to the recorder
+type fortioHTTPRunnable struct {
+	client      fhttp.Fetcher
+	retCodes    map[int]int64
+	sizes       *stats.Histogram
+	headerSizes *stats.Histogram
+	abortOn     int
+	aborter     *periodic.Aborter
+	recorder    *LoadTestRecorder
+}
+
+// Run fetches the url once, it is called by the periodic runner at the target QPS
+func (s *fortioHTTPRunnable) Run(t int) {
+	start := time.Now()
+	code, body, headerSize := s.client.Fetch()
+	s.recorder.Record(code, time.Since(start))
+	s.retCodes[code]++
+	s.sizes.Record(float64(len(body)))
+	s.headerSizes.Record(float64(headerSize))
+	if s.abortOn == code {
+		s.aborter.Abort()
+		logrus.Infof("Aborted run because of code %d - data %s", code, fhttp.DebugSummary(body, 1024))
+	}
+}
+
+// runFortioHTTPTest runs a fortio http test like fhttp.RunHTTPTest does, recording the requests on the recorder
+func runFortioHTTPTest(o *fhttp.HTTPRunnerOptions, recorder *LoadTestRecorder) (*fhttp.HTTPRunnerResults, error) {
+	o.RunType = "HTTP"
+	logrus.Infof("Starting http test for %s with %d threads at %.1f qps", o.URL, o.NumThreads, o.QPS)
+	r := periodic.NewPeriodicRunner(&o.RunnerOptions)
+	defer r.Options().Abort()
+	numThreads := r.Options().NumThreads
+	o.HTTPOptions.Init(o.URL)
+	out := r.Options().Out
+	sizes := stats.NewHistogram(0, 100)
+	headerSizes := stats.NewHistogram(0, 5)
+	total := &fhttp.HTTPRunnerResults{
+		RetCodes: make(map[int]int64),
+		URL:      o.URL,
+		AbortOn:  o.AbortOn,
+	}
+	states := make([]*fortioHTTPRunnable, numThreads)
+	for i := 0; i < numThreads; i++ {
+		// a client, and so a connection, for each thread
+		client := fhttp.NewClient(&o.HTTPOptions)
+		if client == nil {
+			for _, s := range states[:i] {
+				s.client.Close()
+			}
+			return nil, fmt.Errorf("unable to create client %d for %s", i, o.URL)
+		}
+		states[i] = &fortioHTTPRunnable{
+			client:      client,
+			retCodes:    make(map[int]int64),
+			sizes:       sizes.Clone(),
+			headerSizes: headerSizes.Clone(),
+			abortOn:     o.AbortOn,
+			aborter:     r.Options().Stop,
+			recorder:    recorder,
+		}
+		r.Options().Runners[i] = states[i]
+		if o.Exactly <= 0 {
+			code, data, _ := client.Fetch()
+			if !o.AllowInitialErrors && code != http.StatusOK {
+				for _, s := range states[:i+1] {
+					s.client.Close()
+				}
+				return nil, fmt.Errorf("error %d for %s: %q", code, o.URL, string(data))
+			}
+		}
+	}
+
+	total.RunnerResults = r.Run()
+	keys := []int{}
+	for _, s := range states {
+		total.SocketCount += s.client.Close()
+		for k, v := range s.retCodes {
+			if _, exists := total.RetCodes[k]; !exists {
+				keys = append(keys, k)
+			}
+			total.RetCodes[k] += v
+		}
+		sizes.Transfer(s.sizes)
+		headerSizes.Transfer(s.headerSizes)
+	}
+	r.Options().ReleaseRunners()
+	sort.Ints(keys)
+	totalCount := float64(total.DurationHistogram.Count)
+	_, _ = fmt.Fprintf(out, "Sockets used: %d (for perfect keepalive, would be %d)\n", total.SocketCount, r.Options().NumThreads)
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(out, "Code %3d : %d (%.1f %%)\n", k, total.RetCodes[k], 100.*float64(total.RetCodes[k])/totalCount)
+	}
+	total.HeaderSizes = headerSizes.Export()
+	total.Sizes = sizes.Export()
+	return total, nil
+}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 
@@ -13,8 +14,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// WRK2LoadTest is the actual code which invokes gowrk2 to run the load test
-func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+// WRK2LoadTest is the actual code which invokes gowrk2 to run the load test,
+// it returns as soon as the given context is cancelled
+func WRK2LoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
 	qps := opts.HTTPQPS // TODO possibly use translated <=0 to "max" from results/options normalization in periodic/
 	if qps <= 0 {
 		qps = -1 // 0==unitialized struct == default duration, -1 (0 for flag) is max
@@ -39,7 +41,7 @@ func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *period
 		return nil, nil, err
 	}
 	var gres *api.GoWRK2
-	gres, err = api.WRKRun(ro)
+	gres, err = runWRK2(ctx, ro)
 	if err == nil {
 		logrus.Debugf("WRK Result: %+v", gres)
 		res, err = api.TransformWRKToFortio(gres, ro)
@@ -79,3 +81,24 @@ func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *period
 	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
 	return resultsMap, result, nil
 }
+
+type wrk2Run struct {
+	res *api.GoWRK2
+	err error
+}
+
+// runWRK2 runs wrk2 in the background so that the caller is not blocked past cancellation,
+// gowrk2 does not allow killing the process, so it keeps running till the configured duration
+func runWRK2(ctx context.Context, ro *api.GoWRK2Config) (*api.GoWRK2, error) {
+	runChan := make(chan *wrk2Run, 1)
+	go func() {
+		res, err := api.WRKRun(ro)
+		runChan <- &wrk2Run{res: res, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case run := <-runChan:
+		return run.res, run.err
+	}
+}
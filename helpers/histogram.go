@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"math"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+)
+
+// MergeHistogramData merges the exported histograms of several runs into one and
+// computes the given percentiles on the merged data.
+// The histograms must have been recorded using the same resolution, which is
+// the case for all the fortio compatible runs, so that bucket boundaries line up.
+func MergeHistogramData(percentiles []float64, hs ...*stats.HistogramData) *stats.HistogramData {
+	h := stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution)
+	var (
+		count        int64
+		sum, sumSq   float64
+		minV, maxV   float64
+		initialized  bool
+		hasHistogram bool
+	)
+	for _, hd := range hs {
+		if hd == nil || hd.Count == 0 {
+			continue
+		}
+		if len(hd.Data) > 0 {
+			hasHistogram = true
+			for _, b := range hd.Data {
+				// the mid point of a bucket falls in the same bucket of the merged histogram
+				h.RecordN((b.Start+b.End)/2, int(b.Count))
+			}
+		} else {
+			// not all the generators export buckets, the best we can do is to use the average
+			h.RecordN(hd.Avg, int(hd.Count))
+		}
+		n := float64(hd.Count)
+		count += hd.Count
+		sum += hd.Sum
+		sumSq += n * (hd.StdDev*hd.StdDev + hd.Avg*hd.Avg)
+		if !initialized || hd.Min < minV {
+			minV = hd.Min
+		}
+		if !initialized || hd.Max > maxV {
+			maxV = hd.Max
+		}
+		initialized = true
+	}
+	if count == 0 {
+		return &stats.HistogramData{}
+	}
+	res := h.Export()
+	res.Count = count
+	res.Sum = sum
+	res.Min = minV
+	res.Max = maxV
+	res.Avg = sum / float64(count)
+	res.StdDev = math.Sqrt(math.Max(0, sumSq/float64(count)-res.Avg*res.Avg))
+	if !hasHistogram {
+		res.Data = nil
+	} else if len(res.Data) > 0 {
+		res.Data[0].Start = minV
+		res.Data[len(res.Data)-1].End = maxV
+	}
+	// the cumulative percents are computed against the exact total
+	var total int64
+	for i := range res.Data {
+		total += res.Data[i].Count
+		res.Data[i].Percent = 100. * float64(total) / float64(count)
+	}
+	if len(res.Data) > 0 {
+		return res.CalcPercentiles(percentiles)
+	}
+	return res
+}
@@ -13,6 +13,21 @@ import (
 )
 
 func getK8SClientSet(kubeconfig []byte, contextName string) (*kubernetes.Clientset, error) {
+	clientConfig, err := getK8SClientConfig(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig.Timeout = 2 * time.Second
+	clientset, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		err = errors.Wrap(err, "unable to create client set")
+		logrus.Error(err)
+		return nil, err
+	}
+	return clientset, nil
+}
+
+func getK8SClientConfig(kubeconfig []byte, contextName string) (*rest.Config, error) {
 	var clientConfig *rest.Config
 	var err error
 	if len(kubeconfig) == 0 {
@@ -39,14 +54,7 @@ func getK8SClientSet(kubeconfig []byte, contextName string) (*kubernetes.Clients
 			return nil, err
 		}
 	}
-	clientConfig.Timeout = 2 * time.Second
-	clientset, err := kubernetes.NewForConfig(clientConfig)
-	if err != nil {
-		err = errors.Wrap(err, "unable to create client set")
-		logrus.Error(err)
-		return nil, err
-	}
-	return clientset, nil
+	return clientConfig, nil
 }
 
 // FetchKubernetesNodes - function used to fetch nodes metadata
@@ -0,0 +1,406 @@
+package helpers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// loadTestJobOptionsEnv is the env variable holding the options of the load test in the job pod
+	loadTestJobOptionsEnv = "LOAD_TEST_OPTIONS"
+	// loadTestJobSidecarEnv tells the job pod to stop the sidecar proxies once the test is over
+	loadTestJobSidecarEnv = "LOAD_TEST_SIDECAR"
+	loadTestJobContainer  = "meshery-load-test"
+	loadTestJobOptionsKey = "options"
+
+	// the job pod reports back through its logs, the lines carrying data start with these prefixes,
+	// the progress is either a message or the JSON of a snapshot of the live metrics
+	loadTestJobProgressPrefix = "meshery-load-test-progress: "
+	loadTestJobResultPrefix   = "meshery-load-test-result: "
+	loadTestJobErrorPrefix    = "meshery-load-test-error: "
+
+	// loadTestPodStartTimeout bounds the time spent waiting for the image to be pulled and the pod to start
+	loadTestPodStartTimeout = 5 * time.Minute
+	// loadTestJobDeadlineMargin is added to the duration of the test for the job deadline
+	loadTestJobDeadlineMargin = 10 * time.Minute
+)
+
+// KubernetesJobRunner runs load tests as Kubernetes Jobs, so that the load is generated from inside the cluster.
+// The job runs the Meshery image in the job role, which reports its progress and results through the pod logs.
+type KubernetesJobRunner struct {
+	clientset    kubernetes.Interface
+	image        string
+	pollInterval time.Duration
+	// Logs streams the logs of the load generator container, it can be replaced as fake clientsets have no logs
+	Logs func(ctx context.Context, namespace, pod string) (io.ReadCloser, error)
+}
+
+// NewKubernetesJobRunner creates a new instance of KubernetesJobRunner running the given image
+func NewKubernetesJobRunner(clientset kubernetes.Interface, image string) *KubernetesJobRunner {
+	r := &KubernetesJobRunner{
+		clientset:    clientset,
+		image:        image,
+		pollInterval: time.Second,
+	}
+	r.Logs = func(ctx context.Context, namespace, pod string) (io.ReadCloser, error) {
+		return r.clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+			Container: loadTestJobContainer,
+			Follow:    true,
+		}).Context(ctx).Stream()
+	}
+	return r
+}
+
+// NewKubernetesJobRunnerForConfig creates a new instance of KubernetesJobRunner for the cluster of the kubeconfig
+func NewKubernetesJobRunnerForConfig(kubeconfig []byte, contextName, image string) (*KubernetesJobRunner, error) {
+	clientConfig, err := getK8SClientConfig(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+	// the logs are followed for the whole test, the requests are bounded by their context instead of a timeout
+	clientset, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		err = errors.Wrap(err, "unable to create client set")
+		logrus.Error(err)
+		return nil, err
+	}
+	return NewKubernetesJobRunner(clientset, image), nil
+}
+
+// Run runs the load test as a Job in the namespace of the in-cluster options and passes the progress messages
+// reported by the job to the given func, its live metrics are reported to the recorder of the context.
+// The job and its secret are deleted once the test is over.
+func (r *KubernetesJobRunner) Run(ctx context.Context, opts *models.LoadTestOptions, progress func(string)) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if opts.InCluster == nil {
+		err := errors.New("no in-cluster options given for the load test")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	namespace := opts.InCluster.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	jobOpts := *opts
+	jobOpts.InCluster = nil
+	optsJSON, err := json.Marshal(&jobOpts)
+	if err != nil {
+		err = errors.Wrap(err, "unable to marshal the load test options")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	id, _ := uuid.NewV4()
+	name := "meshery-load-test-" + id.String()[:8]
+	defer r.cleanup(namespace, name)
+
+	// the options can hold credentials, they are kept in a secret rather than in the job spec
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: loadTestJobLabels(),
+		},
+		StringData: map[string]string{
+			loadTestJobOptionsKey: string(optsJSON),
+		},
+	}
+	if _, err = r.clientset.CoreV1().Secrets(namespace).Create(secret); err != nil {
+		err = errors.Wrapf(err, "unable to create the secret of the load test job in namespace %s", namespace)
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if _, err = r.clientset.BatchV1().Jobs(namespace).Create(r.newJob(name, opts)); err != nil {
+		err = errors.Wrapf(err, "unable to create the load test job in namespace %s", namespace)
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	progress(fmt.Sprintf("Created job %s in namespace %s, waiting for the load generator to start", name, namespace))
+
+	pod, err := r.waitForPod(ctx, namespace, name)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	progress(fmt.Sprintf("Load generator pod %s started", pod))
+
+	resultsMap, err := r.readResults(ctx, namespace, pod, progress)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		err = errors.Wrap(err, "error while running tests")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	resultsMap["InCluster"] = map[string]interface{}{
+		"namespace":      namespace,
+		"inject_sidecar": opts.InCluster.InjectSidecar,
+	}
+	// the results of both HTTP and gRPC tests hold the runner results at the top level
+	bd, err := json.Marshal(resultsMap)
+	result := &periodic.RunnerResults{}
+	if err == nil {
+		err = json.Unmarshal(bd, result)
+	}
+	if err != nil {
+		err = errors.Wrap(err, "error while converting the results of the load test job")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	return resultsMap, result, nil
+}
+
+func loadTestJobLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       "meshery-load-test",
+		"app.kubernetes.io/managed-by": "meshery",
+	}
+}
+
+func (r *KubernetesJobRunner) newJob(name string, opts *models.LoadTestOptions) *batchv1.Job {
+	backoffLimit := int32(0)
+	deadline := int64((opts.Duration + loadTestJobDeadlineMargin).Seconds())
+	inject, linkerdInject := "false", "disabled"
+	if opts.InCluster.InjectSidecar {
+		inject, linkerdInject = "true", "enabled"
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: loadTestJobLabels(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: loadTestJobLabels(),
+					Annotations: map[string]string{
+						"sidecar.istio.io/inject": inject,
+						"linkerd.io/inject":       linkerdInject,
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    loadTestJobContainer,
+							Image:   r.image,
+							Command: []string{"./meshery"},
+							Env: []corev1.EnvVar{
+								{Name: "MESHERY_ROLE", Value: "job"},
+								{Name: loadTestJobSidecarEnv, Value: fmt.Sprintf("%t", opts.InCluster.InjectSidecar)},
+								{
+									Name: loadTestJobOptionsEnv,
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{Name: name},
+											Key:                  loadTestJobOptionsKey,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// waitForPod waits for the pod of the job to run, or to be over already, and returns its name
+func (r *KubernetesJobRunner) waitForPod(ctx context.Context, namespace, jobName string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, loadTestPodStartTimeout)
+	defer cancel()
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		pods, err := r.clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{
+			LabelSelector: "job-name=" + jobName,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "unable to list the pods of the load test job")
+		}
+		for _, pod := range pods.Items {
+			switch pod.Status.Phase {
+			case corev1.PodRunning, corev1.PodSucceeded, corev1.PodFailed:
+				return pod.Name, nil
+			}
+			for _, cs := range pod.Status.ContainerStatuses {
+				if w := cs.State.Waiting; w != nil && (w.Reason == "ErrImagePull" || w.Reason == "ImagePullBackOff" ||
+					w.Reason == "CreateContainerConfigError" || w.Reason == "InvalidImageName") {
+					return "", fmt.Errorf("the load generator pod cannot start: %s: %s", w.Reason, w.Message)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", errors.Wrap(ctx.Err(), "the load generator pod did not start")
+		case <-ticker.C:
+		}
+	}
+}
+
+// readResults follows the logs of the pod till it reports the results of the test
+func (r *KubernetesJobRunner) readResults(ctx context.Context, namespace, pod string, progress func(string)) (map[string]interface{}, error) {
+	logs, err := r.Logs(ctx, namespace, pod)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the logs of the load generator pod")
+	}
+	defer func() {
+		_ = logs.Close()
+	}()
+	recorder := loadTestRecorderFrom(ctx)
+	scanner := bufio.NewScanner(logs)
+	// the results come on a single line
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, loadTestJobProgressPrefix):
+			msg := strings.TrimPrefix(line, loadTestJobProgressPrefix)
+			if strings.HasPrefix(msg, "{") {
+				snapshot := &models.LoadTestSnapshot{}
+				if err := json.Unmarshal([]byte(msg), snapshot); err != nil {
+					logrus.Debugf("unable to parse the snapshot of load test pod %s: %v", pod, err)
+					continue
+				}
+				recorder.report(snapshot)
+				continue
+			}
+			progress(msg)
+		case strings.HasPrefix(line, loadTestJobErrorPrefix):
+			return nil, errors.New(strings.TrimPrefix(line, loadTestJobErrorPrefix))
+		case strings.HasPrefix(line, loadTestJobResultPrefix):
+			resultsMap := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, loadTestJobResultPrefix)), &resultsMap); err != nil {
+				return nil, errors.Wrap(err, "unable to parse the results of the load generator pod")
+			}
+			return resultsMap, nil
+		default:
+			logrus.Debugf("load test pod %s: %s", pod, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to read the logs of the load generator pod")
+	}
+	return nil, errors.New("the load generator pod stopped without reporting results")
+}
+
+// cleanup deletes the job, its pods and its secret, it runs even when the test was cancelled
+func (r *KubernetesJobRunner) cleanup(namespace, name string) {
+	propagation := metav1.DeletePropagationBackground
+	if err := r.clientset.BatchV1().Jobs(namespace).Delete(name, &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+		logrus.Warnf("unable to delete the load test job %s: %v", name, err)
+	}
+	if err := r.clientset.CoreV1().Secrets(namespace).Delete(name, &metav1.DeleteOptions{}); err != nil {
+		logrus.Warnf("unable to delete the secret of the load test job %s: %v", name, err)
+	}
+}
+
+// RunLoadTestJob runs the load test of a Kubernetes Job from inside its pod, the options are read from
+// the env and the progress and results are written to out for the Meshery server following the logs
+func RunLoadTestJob(ctx context.Context, loadGenerators models.LoadGeneratorRegistryInterface, getenv func(string) string, out io.Writer) error {
+	resultsMap, err := runLoadTestJob(ctx, loadGenerators, getenv(loadTestJobOptionsEnv), out)
+	if strings.EqualFold(getenv(loadTestJobSidecarEnv), "true") {
+		// the job only completes once the proxies are gone as well
+		stopSidecars()
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(out, loadTestJobErrorPrefix+strings.Replace(err.Error(), "\n", " ", -1))
+		return err
+	}
+	bd, err := json.Marshal(resultsMap)
+	if err != nil {
+		err = errors.Wrap(err, "unable to marshal the results")
+		_, _ = fmt.Fprintln(out, loadTestJobErrorPrefix+err.Error())
+		return err
+	}
+	_, _ = fmt.Fprintln(out, loadTestJobResultPrefix+string(bd))
+	return nil
+}
+
+func runLoadTestJob(ctx context.Context, loadGenerators models.LoadGeneratorRegistryInterface, optsJSON string, out io.Writer) (map[string]interface{}, error) {
+	opts := &models.LoadTestOptions{}
+	if err := json.Unmarshal([]byte(optsJSON), opts); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the load test options")
+	}
+	gen, err := loadGenerators.Get(opts.LoadGenerator)
+	if err == nil {
+		err = gen.Validate(opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(out, "%sRunning load test %s with %s from inside the cluster\n", loadTestJobProgressPrefix, opts.Name, gen.Name())
+	duration := opts.Duration
+	if len(opts.Stages) > 0 {
+		duration = models.TotalDuration(opts.Stages)
+	}
+	recorder := NewLoadTestRecorder(duration)
+	stopSnapshots := writeLoadTestSnapshots(recorder, out)
+	resultsMap, _, err := RunLoadTest(WithLoadTestRecorder(ctx, recorder), gen, opts)
+	stopSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(out, "%sLoad test completed\n", loadTestJobProgressPrefix)
+	return resultsMap, nil
+}
+
+// writeLoadTestSnapshots writes the snapshots of the recorder as progress lines every snapshot interval
+// until the returned func is called
+func writeLoadTestSnapshots(recorder *LoadTestRecorder, out io.Writer) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(SnapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				bd, err := json.Marshal(recorder.Snapshot())
+				if err != nil {
+					logrus.Debugf("unable to marshal the snapshot of the load test: %v", err)
+					continue
+				}
+				_, _ = fmt.Fprintf(out, "%s%s\n", loadTestJobProgressPrefix, bd)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// stopSidecars asks the Istio and Linkerd proxies of the pod to exit
+func stopSidecars() {
+	client := &http.Client{Timeout: 2 * time.Second}
+	for _, u := range []string{"http://localhost:15020/quitquitquit", "http://localhost:4191/shutdown"} {
+		resp, err := client.Post(u, "text/plain", nil)
+		if err != nil {
+			logrus.Debugf("unable to stop the sidecar at %s: %v", u, err)
+			continue
+		}
+		_ = resp.Body.Close()
+	}
+}
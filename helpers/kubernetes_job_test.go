@@ -0,0 +1,200 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testJobNamespace = "perf"
+
+// newFakeJobRunner returns a runner on a fake clientset which starts a pod for every job created,
+// the logs of the pods are the given lines
+func newFakeJobRunner(t *testing.T, logs ...string) (*KubernetesJobRunner, *fake.Clientset) {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      job.Name + "-x7k2p",
+				Namespace: action.GetNamespace(),
+				Labels:    map[string]string{"job-name": job.Name},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		// the default reactor creates the job itself
+		return false, nil, clientset.Tracker().Add(pod)
+	})
+	runner := NewKubernetesJobRunner(clientset, "layer5/meshery:test")
+	runner.pollInterval = 10 * time.Millisecond
+	runner.Logs = func(ctx context.Context, namespace, pod string) (io.ReadCloser, error) {
+		if namespace != testJobNamespace {
+			t.Errorf("logs read in namespace %s, expected %s", namespace, testJobNamespace)
+		}
+		return ioutil.NopCloser(strings.NewReader(strings.Join(logs, "\n") + "\n")), nil
+	}
+	return runner, clientset
+}
+
+func assertJobCleanedUp(t *testing.T, clientset *fake.Clientset) {
+	jobs, err := clientset.BatchV1().Jobs(testJobNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.Items) != 0 {
+		t.Errorf("%d jobs left after the test", len(jobs.Items))
+	}
+	secrets, err := clientset.CoreV1().Secrets(testJobNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets.Items) != 0 {
+		t.Errorf("%d secrets left after the test", len(secrets.Items))
+	}
+}
+
+func TestKubernetesJobRunnerRun(t *testing.T) {
+	runner, clientset := newFakeJobRunner(t,
+		"starting",
+		loadTestJobProgressPrefix+"Running load test perf with fortio from inside the cluster",
+		loadTestJobProgressPrefix+`{"elapsed_seconds":1,"duration_seconds":2,"count":42,"qps":40,"p50_ms":3}`,
+		loadTestJobResultPrefix+`{"RunType":"HTTP","ActualQPS":41.5,"DurationHistogram":{"Count":42}}`,
+	)
+	recorder := NewLoadTestRecorder(2 * time.Second)
+	opts := &models.LoadTestOptions{
+		Name:      "perf",
+		URL:       "http://productpage:9080",
+		Duration:  2 * time.Second,
+		InCluster: &models.InClusterOptions{Namespace: testJobNamespace, InjectSidecar: true},
+	}
+	var messages []string
+	resultsMap, result, err := runner.Run(WithLoadTestRecorder(context.Background(), recorder), opts, func(msg string) {
+		messages = append(messages, msg)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages) != 3 || messages[2] != "Running load test perf with fortio from inside the cluster" {
+		t.Errorf("unexpected progress messages: %q", messages)
+	}
+	if s := recorder.Snapshot(); s.Count != 42 || s.P50Ms != 3 {
+		t.Errorf("the snapshot of the job was not reported to the recorder: %+v", s)
+	}
+	if result.ActualQPS != 41.5 {
+		t.Errorf("unexpected qps %g", result.ActualQPS)
+	}
+	inCluster, ok := resultsMap["InCluster"].(map[string]interface{})
+	if !ok || inCluster["namespace"] != testJobNamespace || inCluster["inject_sidecar"] != true {
+		t.Errorf("unexpected in-cluster options of the results: %v", resultsMap["InCluster"])
+	}
+
+	var createdJob *batchv1.Job
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "create" && action.GetResource().Resource == "jobs" {
+			createdJob = action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		}
+	}
+	if createdJob == nil {
+		t.Fatal("no job was created")
+	}
+	if inject := createdJob.Spec.Template.Annotations["sidecar.istio.io/inject"]; inject != "true" {
+		t.Errorf("unexpected sidecar injection annotation %q", inject)
+	}
+	container := createdJob.Spec.Template.Spec.Containers[0]
+	if container.Image != "layer5/meshery:test" {
+		t.Errorf("unexpected image %s", container.Image)
+	}
+	for _, env := range container.Env {
+		if env.Name == loadTestJobOptionsEnv && env.ValueFrom == nil {
+			t.Error("the options of the test are not read from the secret")
+		}
+	}
+	assertJobCleanedUp(t, clientset)
+}
+
+func TestKubernetesJobRunnerRunError(t *testing.T) {
+	runner, clientset := newFakeJobRunner(t,
+		loadTestJobProgressPrefix+"Running load test perf with fortio from inside the cluster",
+		loadTestJobErrorPrefix+"error while running tests: connection refused",
+	)
+	opts := &models.LoadTestOptions{
+		Name:      "perf",
+		URL:       "http://productpage:9080",
+		Duration:  time.Second,
+		InCluster: &models.InClusterOptions{Namespace: testJobNamespace},
+	}
+	_, _, err := runner.Run(context.Background(), opts, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("expected the error of the job, got %v", err)
+	}
+	assertJobCleanedUp(t, clientset)
+}
+
+func TestRunLoadTestJobWritesSnapshots(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	registry, err := NewLoadGeneratorRegistry(NewNativeLoadGenerator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	optsJSON, err := json.Marshal(&models.LoadTestOptions{
+		Name:           "perf",
+		URL:            srv.URL,
+		HTTPQPS:        20,
+		HTTPNumThreads: 2,
+		Duration:       1500 * time.Millisecond,
+		LoadGenerator:  models.NativeLG,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{loadTestJobOptionsEnv: string(optsJSON)}
+	out := &bytes.Buffer{}
+	if err := RunLoadTestJob(context.Background(), registry, func(k string) string { return env[k] }, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var snapshots int
+	var result bool
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, loadTestJobProgressPrefix+"{"):
+			s := &models.LoadTestSnapshot{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, loadTestJobProgressPrefix)), s); err != nil {
+				t.Fatalf("invalid snapshot %q: %v", line, err)
+			}
+			if s.Count == 0 {
+				t.Errorf("the snapshot has no requests: %q", line)
+			}
+			snapshots++
+		case strings.HasPrefix(line, loadTestJobResultPrefix):
+			result = true
+		}
+	}
+	if snapshots == 0 {
+		t.Error("no snapshot was written while the test ran")
+	}
+	if !result {
+		t.Error("no result was written")
+	}
+}
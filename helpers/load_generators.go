@@ -0,0 +1,217 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+)
+
+// httpFeatures are provided by Meshery around any HTTP load generator
+var httpFeatures = []models.LoadGeneratorFeature{
+	models.HTTPFeature,
+	models.ClientTLSFeature,
+	models.ScenarioFeature,
+	models.StagesFeature,
+}
+
+// FortioLoadGenerator runs load tests with fortio
+type FortioLoadGenerator struct{}
+
+// NewFortioLoadGenerator creates a new instance of FortioLoadGenerator
+func NewFortioLoadGenerator() *FortioLoadGenerator {
+	return &FortioLoadGenerator{}
+}
+
+// Name returns the name of the generator
+func (g *FortioLoadGenerator) Name() models.LoadGenerator {
+	return models.FortioLG
+}
+
+// Capabilities returns the features supported by fortio
+func (g *FortioLoadGenerator) Capabilities() *models.LoadGeneratorCapabilities {
+	return &models.LoadGeneratorCapabilities{
+		Name:        g.Name().Name(),
+		Description: "Fortio load generator, for HTTP and gRPC services",
+		Features: append([]models.LoadGeneratorFeature{
+			models.GRPCFeature,
+			models.CustomRequestFeature,
+			models.ConnectionOptionsFeature,
+			models.StopConditionsFeature,
+		}, httpFeatures...),
+	}
+}
+
+// Validate checks that fortio can run a load test with the given options
+func (g *FortioLoadGenerator) Validate(opts *models.LoadTestOptions) error {
+	if err := models.ValidateLoadGeneratorFeatures(g, opts); err != nil {
+		return err
+	}
+	if opts.IsGRPC {
+		return nil
+	}
+	if _, err := sharedHTTPOptions(opts); err != nil {
+		return err
+	}
+	for _, ep := range opts.Endpoints {
+		if ep.Method == "" {
+			continue
+		}
+		epOpts := *opts
+		epOpts.Method = ep.Method
+		if _, err := sharedHTTPOptions(&epOpts); err != nil {
+			return fmt.Errorf("invalid request for endpoint %s: %v", ep.Name, err)
+		}
+	}
+	return nil
+}
+
+// Run runs the load test with fortio
+func (g *FortioLoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	return FortioLoadTest(ctx, opts)
+}
+
+// WRK2LoadGenerator runs load tests with wrk2
+type WRK2LoadGenerator struct{}
+
+// NewWRK2LoadGenerator creates a new instance of WRK2LoadGenerator
+func NewWRK2LoadGenerator() *WRK2LoadGenerator {
+	return &WRK2LoadGenerator{}
+}
+
+// Name returns the name of the generator
+func (g *WRK2LoadGenerator) Name() models.LoadGenerator {
+	return models.Wrk2LG
+}
+
+// Capabilities returns the features supported by wrk2, which can not be stopped before the end of its run
+// and so does not support the stop conditions
+func (g *WRK2LoadGenerator) Capabilities() *models.LoadGeneratorCapabilities {
+	return &models.LoadGeneratorCapabilities{
+		Name:        g.Name().Name(),
+		Description: "wrk2 load generator, for HTTP services with a constant throughput",
+		Features:    append([]models.LoadGeneratorFeature{}, httpFeatures...),
+	}
+}
+
+// Validate checks that wrk2 can run a load test with the given options
+func (g *WRK2LoadGenerator) Validate(opts *models.LoadTestOptions) error {
+	return models.ValidateLoadGeneratorFeatures(g, opts)
+}
+
+// Run runs the load test with wrk2
+func (g *WRK2LoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	return WRK2LoadTest(ctx, opts)
+}
+
+// NativeLoadGenerator runs open model load tests in process
+type NativeLoadGenerator struct{}
+
+// NewNativeLoadGenerator creates a new instance of NativeLoadGenerator
+func NewNativeLoadGenerator() *NativeLoadGenerator {
+	return &NativeLoadGenerator{}
+}
+
+// Name returns the name of the generator
+func (g *NativeLoadGenerator) Name() models.LoadGenerator {
+	return models.NativeLG
+}
+
+// Capabilities returns the features supported by the native generator
+func (g *NativeLoadGenerator) Capabilities() *models.LoadGeneratorCapabilities {
+	return &models.LoadGeneratorCapabilities{
+		Name:        g.Name().Name(),
+		Description: "Built-in load generator for HTTP services, with constant or Poisson arrivals independent of the response times",
+		Features: append([]models.LoadGeneratorFeature{
+			models.CustomRequestFeature,
+			models.ConnectionOptionsFeature,
+			models.OpenModelFeature,
+			models.StopConditionsFeature,
+		}, httpFeatures...),
+	}
+}
+
+// Validate checks that the native generator can run a load test with the given options
+func (g *NativeLoadGenerator) Validate(opts *models.LoadTestOptions) error {
+	if err := models.ValidateLoadGeneratorFeatures(g, opts); err != nil {
+		return err
+	}
+	if opts.HTTP10 {
+		return errors.New("the native load generator does not support HTTP/1.0")
+	}
+	if len(opts.Stages) > 0 {
+		for _, stage := range opts.Stages {
+			if stage.QPS <= 0 {
+				return fmt.Errorf("the native load generator needs a qps for stage %s", stage.Name)
+			}
+		}
+	} else if opts.HTTPQPS <= 0 {
+		return errors.New("the native load generator needs a qps")
+	}
+	return nil
+}
+
+// Run runs the load test with the native generator
+func (g *NativeLoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	return NativeLoadTest(ctx, opts)
+}
+
+// LoadGeneratorRegistry keeps track of the load generators available for load tests
+type LoadGeneratorRegistry struct {
+	generators map[models.LoadGenerator]models.LoadGeneratorInterface
+	gLock      *sync.RWMutex
+}
+
+// NewLoadGeneratorRegistry creates a new instance of LoadGeneratorRegistry with the given generators
+func NewLoadGeneratorRegistry(gens ...models.LoadGeneratorInterface) (*LoadGeneratorRegistry, error) {
+	r := &LoadGeneratorRegistry{
+		generators: map[models.LoadGenerator]models.LoadGeneratorInterface{},
+		gLock:      &sync.RWMutex{},
+	}
+	for _, gen := range gens {
+		if err := r.Register(gen); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a load generator to the registry
+func (r *LoadGeneratorRegistry) Register(gen models.LoadGeneratorInterface) error {
+	r.gLock.Lock()
+	defer r.gLock.Unlock()
+	if _, ok := r.generators[gen.Name()]; ok {
+		return fmt.Errorf("load generator %s is already registered", gen.Name())
+	}
+	r.generators[gen.Name()] = gen
+	return nil
+}
+
+// Get returns the load generator registered with the given name
+func (r *LoadGeneratorRegistry) Get(name models.LoadGenerator) (models.LoadGeneratorInterface, error) {
+	r.gLock.RLock()
+	defer r.gLock.RUnlock()
+	gen, ok := r.generators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown load generator: %s", name)
+	}
+	return gen, nil
+}
+
+// List returns all the registered load generators sorted by name
+func (r *LoadGeneratorRegistry) List() []models.LoadGeneratorInterface {
+	r.gLock.RLock()
+	defer r.gLock.RUnlock()
+	gens := make([]models.LoadGeneratorInterface, 0, len(r.generators))
+	for _, gen := range r.generators {
+		gens = append(gens, gen)
+	}
+	sort.Slice(gens, func(i, j int) bool {
+		return gens[i].Name() < gens[j].Name()
+	})
+	return gens
+}
@@ -0,0 +1,258 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// workerRunPath is the path of the worker API running a share of a load test
+	workerRunPath = "/api/worker/run"
+	// workerStartDelay leaves the workers the time to receive their share before the synchronised start
+	workerStartDelay = 2 * time.Second
+)
+
+// LoadTestCoordinator fans load tests out to remote workers and merges their results
+type LoadTestCoordinator struct {
+	workers []string
+	token   string
+	client  *http.Client
+}
+
+// NewLoadTestCoordinator creates a new instance of LoadTestCoordinator for the given worker base URLs,
+// the token is sent to the workers when not empty. The workers behind https URLs are verified with the
+// CA certificates of the PEM file caFile, or with those of the system when it is empty.
+func NewLoadTestCoordinator(workers []string, token, caFile string) (*LoadTestCoordinator, error) {
+	if len(workers) == 0 {
+		return nil, errors.New("no workers given to the load test coordinator")
+	}
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read the CA certificates of the workers")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no CA certificate found for the workers")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	c := &LoadTestCoordinator{
+		token: token,
+		// the requests last as long as the tests, they are bounded by their context instead of a timeout
+		client: &http.Client{Transport: transport},
+	}
+	for _, w := range workers {
+		w = strings.TrimRight(strings.TrimSpace(w), "/")
+		if w == "" {
+			continue
+		}
+		if !strings.HasPrefix(w, "http://") && !strings.HasPrefix(w, "https://") {
+			w = "http://" + w
+		}
+		c.workers = append(c.workers, w)
+	}
+	if len(c.workers) == 0 {
+		return nil, errors.New("no workers given to the load test coordinator")
+	}
+	for _, w := range c.workers {
+		if !secureWorker(w) {
+			logrus.Warnf("the load tests are sent to worker %s over plain http, the worker token goes in clear text and the tests with credentials are not distributed", w)
+		}
+	}
+	return c, nil
+}
+
+// secureWorker tells whether what is sent to the worker can not be read on the way, as it is reached
+// over https or on the loopback interface
+func secureWorker(worker string) bool {
+	u, err := url.Parse(worker)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "https" || IsLoopbackHost(u.Hostname())
+}
+
+// credentialHeaders are the headers carrying the credentials of a test
+var credentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// hasCredentials tells whether the test sends credentials to the endpoints under test: a client key,
+// a bearer token, basic auth or a header carrying credentials, including those of the endpoints of a scenario
+func hasCredentials(opts *models.LoadTestOptions) bool {
+	if opts.Key != "" || opts.BearerToken != "" || opts.UserCredentials != "" {
+		return true
+	}
+	headers := []map[string]string{opts.Headers}
+	for _, e := range opts.Endpoints {
+		headers = append(headers, e.Headers)
+	}
+	for _, hdrs := range headers {
+		for k := range hdrs {
+			if credentialHeaders[http.CanonicalHeaderKey(k)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsLoopbackHost tells whether the host name or address is the one of the loopback interface
+func IsLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Workers returns the base URLs of the workers
+func (c *LoadTestCoordinator) Workers() []string {
+	return append([]string{}, c.workers...)
+}
+
+// Run splits the load test evenly between the workers, which start at the same time,
+// and merges their histograms into a single result. The result of each worker is kept under "workers".
+func (c *LoadTestCoordinator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if opts.IsGRPC {
+		err := errors.New("distributed load tests only support HTTP at the moment")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	startAt := time.Now().Add(workerStartDelay)
+	resultsMaps := make([]map[string]interface{}, len(c.workers))
+	results := make([]*fhttp.HTTPRunnerResults, len(c.workers))
+	errs := make([]error, len(c.workers))
+	wg := &sync.WaitGroup{}
+	for i, worker := range c.workers {
+		wg.Add(1)
+		go func(i int, worker string) {
+			defer wg.Done()
+			share := workerShare(opts, i, len(c.workers))
+			resultsMap, err := c.runOnWorker(ctx, worker, share, startAt)
+			if err == nil {
+				resultsMaps[i] = resultsMap
+				results[i], err = toHTTPRunnerResults(resultsMap)
+			}
+			if err != nil {
+				errs[i] = errors.Wrapf(err, "error while running the load test on worker %s", worker)
+				// the merged result would be incomplete
+				cancel()
+			}
+		}(i, worker)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			logrus.Error(err)
+			return nil, nil, err
+		}
+	}
+
+	agg := aggregateHTTPResults(opts.Name+" -_- distributed", opts.URL, results, false)
+	resultsMap, err := toResultsMap(agg)
+	if err != nil {
+		return nil, nil, err
+	}
+	workers := make([]interface{}, len(c.workers))
+	for i, worker := range c.workers {
+		workers[i] = map[string]interface{}{
+			"worker": worker,
+			"result": resultsMaps[i],
+		}
+		// the workers check the stop conditions on their own share
+		if _, ok := resultsMap["Aborted"]; !ok {
+			if reason := (&models.MesheryResult{Result: resultsMaps[i]}).AbortReason(); reason != "" {
+				markAborted(resultsMap, fmt.Sprintf("worker %s %s", worker, reason), time.Now())
+			}
+		}
+	}
+	resultsMap["workers"] = workers
+	return resultsMap, agg.Result(), nil
+}
+
+func (c *LoadTestCoordinator) runOnWorker(ctx context.Context, worker string, opts *models.LoadTestOptions, startAt time.Time) (map[string]interface{}, error) {
+	if hasCredentials(opts) && !secureWorker(worker) {
+		return nil, errors.New("the credentials of the test are only sent to workers reached over https")
+	}
+	body, err := json.Marshal(&models.WorkerLoadTestRequest{
+		Options: opts,
+		StartAt: startAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, worker+workerRunPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set(models.WorkerTokenHeader, c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	workerResp := &models.WorkerLoadTestResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(workerResp); err != nil {
+		return nil, errors.Wrapf(err, "unable to read the response, status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || workerResp.Error != "" {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, workerResp.Error)
+	}
+	return workerResp.Result, nil
+}
+
+// workerShare returns the options of the i-th of n workers: the QPS is split evenly,
+// the threads as evenly as possible with at least one per worker
+func workerShare(opts *models.LoadTestOptions, i, n int) *models.LoadTestOptions {
+	share := *opts
+	share.Name = fmt.Sprintf("%s -_- worker-%d", opts.Name, i+1)
+	share.HTTPQPS = opts.HTTPQPS / float64(n)
+	share.HTTPNumThreads = opts.HTTPNumThreads / n
+	if i < opts.HTTPNumThreads%n {
+		share.HTTPNumThreads++
+	}
+	if share.HTTPNumThreads < 1 {
+		share.HTTPNumThreads = 1
+	}
+	if len(opts.Stages) > 0 {
+		share.Stages = make([]*models.LoadTestStage, len(opts.Stages))
+		for j, stage := range opts.Stages {
+			s := *stage
+			s.QPS = stage.QPS / float64(n)
+			share.Stages[j] = &s
+		}
+	}
+	return &share
+}
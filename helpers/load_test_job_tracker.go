@@ -0,0 +1,290 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultLoadTestJobRetention - how long the finished jobs are kept by default
+const DefaultLoadTestJobRetention = 24 * time.Hour
+
+// LoadTestJobTracker tracks load test jobs, their progress messages and subscribers,
+// the jobs are pruned once they have been finished for longer than the retention
+type LoadTestJobTracker struct {
+	jobs      map[uuid.UUID]*loadTestJobEntry
+	jobsLock  *sync.Mutex
+	persister *models.BitCaskLoadTestJobPersister
+	retention time.Duration
+}
+
+type loadTestJobEntry struct {
+	job         *models.LoadTestJob
+	cancel      context.CancelFunc
+	subscribers map[chan *models.LoadTestResponse]struct{}
+}
+
+// NewLoadTestJobTracker creates a new instance of LoadTestJobTracker and loads the jobs persisted earlier
+// which are still within the retention
+func NewLoadTestJobTracker(persister *models.BitCaskLoadTestJobPersister, retention time.Duration) *LoadTestJobTracker {
+	if retention <= 0 {
+		retention = DefaultLoadTestJobRetention
+	}
+	a := &LoadTestJobTracker{
+		jobs:      map[uuid.UUID]*loadTestJobEntry{},
+		jobsLock:  &sync.Mutex{},
+		persister: persister,
+		retention: retention,
+	}
+	if persister == nil {
+		return a
+	}
+	jobs, err := persister.GetJobs()
+	if err != nil {
+		logrus.Warnf("unable to load the persisted load test jobs: %v", err)
+		return a
+	}
+	for _, job := range jobs {
+		if !job.Status.IsTerminal() {
+			// the process running this job is gone, so it will never finish
+			job.Status = models.LoadTestJobFailed
+			job.Messages = append(job.Messages, &models.LoadTestResponse{
+				Status:  models.LoadTestError,
+				Message: "load test was interrupted by a Meshery restart",
+				JobID:   job.ID.String(),
+			})
+			job.UpdatedAt = time.Now()
+			a.persist(job)
+		}
+		a.jobs[job.ID] = &loadTestJobEntry{
+			job:         job,
+			subscribers: map[chan *models.LoadTestResponse]struct{}{},
+		}
+	}
+	a.PruneJobs(time.Now())
+	return a
+}
+
+// Run prunes the finished jobs regularly until the context is done
+func (a *LoadTestJobTracker) Run(ctx context.Context) {
+	interval := time.Hour
+	if a.retention < interval {
+		interval = a.retention
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.PruneJobs(now)
+		}
+	}
+}
+
+// PruneJobs removes the jobs which were finished before the retention, it returns how many were removed
+func (a *LoadTestJobTracker) PruneJobs(now time.Time) int {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	before := now.Add(-a.retention)
+	pruned := 0
+	for id, entry := range a.jobs {
+		if !entry.job.Status.IsTerminal() || !entry.job.UpdatedAt.Before(before) {
+			continue
+		}
+		if a.persister != nil {
+			if err := a.persister.DeleteJob(id); err != nil {
+				logrus.Warnf("unable to delete load test job %s: %v", id, err)
+				continue
+			}
+		}
+		delete(a.jobs, id)
+		pruned++
+	}
+	// the store is only used with the lock held, so it can be compacted
+	if pruned > 0 && a.persister != nil {
+		if err := a.persister.Compact(); err != nil {
+			logrus.Warnf("unable to compact the load test jobs: %v", err)
+		}
+	}
+	if pruned > 0 {
+		logrus.Infof("pruned %d load test jobs finished for more than %v", pruned, a.retention)
+	}
+	return pruned
+}
+
+// AddJob registers a new job along with the function which cancels it
+func (a *LoadTestJobTracker) AddJob(ctx context.Context, job *models.LoadTestJob, cancel context.CancelFunc) error {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	if _, ok := a.jobs[job.ID]; ok {
+		return fmt.Errorf("job with id %s already exists", job.ID)
+	}
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	a.jobs[job.ID] = &loadTestJobEntry{
+		job:         job,
+		cancel:      cancel,
+		subscribers: map[chan *models.LoadTestResponse]struct{}{},
+	}
+	a.persist(job)
+	return nil
+}
+
+// GetJob retrieves a copy of the job with the given id
+func (a *LoadTestJobTracker) GetJob(ctx context.Context, id uuid.UUID) (*models.LoadTestJob, error) {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("job with id %s not found", id)
+	}
+	return copyLoadTestJob(entry.job), nil
+}
+
+// GetJobs retrieves copies of all the jobs, filtered by status when status is not empty
+func (a *LoadTestJobTracker) GetJobs(ctx context.Context, status models.LoadTestJobStatus) ([]*models.LoadTestJob, error) {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	jobs := []*models.LoadTestJob{}
+	for _, entry := range a.jobs {
+		if status != "" && entry.job.Status != status {
+			continue
+		}
+		jobs = append(jobs, copyLoadTestJob(entry.job))
+	}
+	return jobs, nil
+}
+
+// PublishMessage records the message on the job and forwards it to all subscribers,
+// the progress messages only replace the latest progress of the job and are not persisted
+func (a *LoadTestJobTracker) PublishMessage(ctx context.Context, id uuid.UUID, msg *models.LoadTestResponse) {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		logrus.Warnf("received a message for an unknown load test job: %s", id)
+		return
+	}
+	msg.JobID = id.String()
+	progress := msg.Status == models.LoadTestProgress
+	if progress {
+		entry.job.Progress = msg.Snapshot
+	} else {
+		entry.job.Messages = append(entry.job.Messages, msg)
+		entry.job.UpdatedAt = time.Now()
+	}
+	if entry.job.Status == models.LoadTestJobQueued {
+		entry.job.Status = models.LoadTestJobRunning
+	}
+	for sub := range entry.subscribers {
+		select {
+		case sub <- msg:
+		default:
+			// a later snapshot supersedes a dropped one
+			if !progress {
+				logrus.Warnf("subscriber of load test job %s is not keeping up, dropping message", id)
+			}
+		}
+	}
+	if !progress {
+		a.persist(entry.job)
+	}
+}
+
+// UpdateJobStatus updates the job status, a terminal status closes all subscriptions
+func (a *LoadTestJobTracker) UpdateJobStatus(ctx context.Context, id uuid.UUID, status models.LoadTestJobStatus, resultID string) {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		logrus.Warnf("received a status update for an unknown load test job: %s", id)
+		return
+	}
+	entry.job.Status = status
+	if resultID != "" {
+		entry.job.ResultID = resultID
+	}
+	entry.job.UpdatedAt = time.Now()
+	if status.IsTerminal() {
+		for sub := range entry.subscribers {
+			close(sub)
+		}
+		entry.subscribers = map[chan *models.LoadTestResponse]struct{}{}
+		entry.cancel = nil
+	}
+	a.persist(entry.job)
+}
+
+// Subscribe returns the messages published so far, a channel for the upcoming ones and a func to unsubscribe
+func (a *LoadTestJobTracker) Subscribe(ctx context.Context, id uuid.UUID) ([]*models.LoadTestResponse, <-chan *models.LoadTestResponse, func(), error) {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("job with id %s not found", id)
+	}
+	history := make([]*models.LoadTestResponse, len(entry.job.Messages))
+	copy(history, entry.job.Messages)
+
+	sub := make(chan *models.LoadTestResponse, 100)
+	if entry.job.Status.IsTerminal() {
+		close(sub)
+		return history, sub, func() {}, nil
+	}
+	if entry.job.Progress != nil {
+		history = append(history, &models.LoadTestResponse{
+			Status:   models.LoadTestProgress,
+			JobID:    id.String(),
+			Snapshot: entry.job.Progress,
+		})
+	}
+	entry.subscribers[sub] = struct{}{}
+	unsubscribe := func() {
+		a.jobsLock.Lock()
+		defer a.jobsLock.Unlock()
+		if _, ok := entry.subscribers[sub]; ok {
+			delete(entry.subscribers, sub)
+			close(sub)
+		}
+	}
+	return history, sub, unsubscribe, nil
+}
+
+// CancelJob cancels a running job
+func (a *LoadTestJobTracker) CancelJob(ctx context.Context, id uuid.UUID) error {
+	a.jobsLock.Lock()
+	defer a.jobsLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		return fmt.Errorf("job with id %s not found", id)
+	}
+	if entry.job.Status.IsTerminal() || entry.cancel == nil {
+		return fmt.Errorf("job with id %s is not running", id)
+	}
+	entry.cancel()
+	return nil
+}
+
+func (a *LoadTestJobTracker) persist(job *models.LoadTestJob) {
+	if a.persister == nil {
+		return
+	}
+	if err := a.persister.WriteJob(job); err != nil {
+		logrus.Warnf("unable to persist load test job %s: %v", job.ID, err)
+	}
+}
+
+func copyLoadTestJob(job *models.LoadTestJob) *models.LoadTestJob {
+	jobCopy := *job
+	jobCopy.Messages = make([]*models.LoadTestResponse, len(job.Messages))
+	copy(jobCopy.Messages, job.Messages)
+	return &jobCopy
+}
@@ -0,0 +1,199 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+)
+
+const (
+	// recorderWindow is the number of seconds the rolling latencies and error rate of a snapshot cover
+	recorderWindow = 10
+	// SnapshotInterval is how often the live metrics of a running load test are reported
+	SnapshotInterval = time.Second
+)
+
+type recorderContextKey struct{}
+
+// recorderBucket holds the requests completed during one second of the test
+type recorderBucket struct {
+	second    int
+	durations *stats.Histogram
+	count     int64
+	errors    int64
+}
+
+// LoadTestRecorder collects the outcome of the requests of a running load test to report live metrics,
+// its methods can be called on a nil recorder which records nothing
+type LoadTestRecorder struct {
+	start    time.Time
+	duration time.Duration
+	count    int64
+	errors   int64
+	retCodes map[int]int64
+	buckets  []*recorderBucket
+
+	lastCount int64
+	lastTime  time.Time
+	rLock     *sync.Mutex
+
+	// next is also given the requests, like the recorder of the whole test for the one of a run
+	next *LoadTestRecorder
+	// observe is called with the code of every request
+	observe func(code int)
+	// reported is the latest snapshot of a test running elsewhere, like in a Kubernetes Job
+	reported *models.LoadTestSnapshot
+}
+
+// NewLoadTestRecorder creates a new instance of LoadTestRecorder for a test of the given duration starting now
+func NewLoadTestRecorder(duration time.Duration) *LoadTestRecorder {
+	return newWindowRecorder(duration, recorderWindow*time.Second, nil)
+}
+
+// newWindowRecorder creates a recorder whose rolling metrics cover the given window, rounded up to the second,
+// the requests are recorded on next as well
+func newWindowRecorder(duration, window time.Duration, next *LoadTestRecorder) *LoadTestRecorder {
+	seconds := int((window + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	now := time.Now()
+	r := &LoadTestRecorder{
+		start:    now,
+		duration: duration,
+		retCodes: map[int]int64{},
+		buckets:  make([]*recorderBucket, seconds),
+		lastTime: now,
+		rLock:    &sync.Mutex{},
+		next:     next,
+	}
+	for i := range r.buckets {
+		r.buckets[i] = &recorderBucket{
+			second:    -1,
+			durations: stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution),
+		}
+	}
+	return r
+}
+
+// WithLoadTestRecorder returns a context carrying the recorder, the load generators supporting live metrics
+// record their requests on it
+func WithLoadTestRecorder(ctx context.Context, r *LoadTestRecorder) context.Context {
+	return context.WithValue(ctx, recorderContextKey{}, r)
+}
+
+// loadTestRecorderFrom returns the recorder of the context, nil when there is none
+func loadTestRecorderFrom(ctx context.Context) *LoadTestRecorder {
+	r, _ := ctx.Value(recorderContextKey{}).(*LoadTestRecorder)
+	return r
+}
+
+// Record records a completed request, the code is -1 for socket errors
+func (r *LoadTestRecorder) Record(code int, latency time.Duration) {
+	if r == nil {
+		return
+	}
+	r.next.Record(code, latency)
+	r.record(code, latency)
+	if r.observe != nil {
+		r.observe(code)
+	}
+}
+
+func (r *LoadTestRecorder) record(code int, latency time.Duration) {
+	failed := code < 200 || code >= 400
+	r.rLock.Lock()
+	defer r.rLock.Unlock()
+	r.count++
+	r.retCodes[code]++
+	second := int(time.Since(r.start) / time.Second)
+	b := r.buckets[second%len(r.buckets)]
+	if b.second != second {
+		b.second = second
+		b.durations.Reset()
+		b.count = 0
+		b.errors = 0
+	}
+	b.durations.Record(latency.Seconds())
+	b.count++
+	if failed {
+		r.errors++
+		b.errors++
+	}
+}
+
+// report records the snapshot of a test running elsewhere, it is returned by Snapshot from then on
+func (r *LoadTestRecorder) report(s *models.LoadTestSnapshot) {
+	if r == nil {
+		return
+	}
+	r.rLock.Lock()
+	defer r.rLock.Unlock()
+	r.reported = s
+}
+
+// Snapshot returns the metrics of the test so far, the QPS is the one achieved since the previous snapshot
+func (r *LoadTestRecorder) Snapshot() *models.LoadTestSnapshot {
+	if r == nil {
+		return nil
+	}
+	r.rLock.Lock()
+	defer r.rLock.Unlock()
+	if r.reported != nil {
+		s := *r.reported
+		return &s
+	}
+	now := time.Now()
+	s := &models.LoadTestSnapshot{
+		Time:            now,
+		ElapsedSeconds:  now.Sub(r.start).Seconds(),
+		DurationSeconds: r.duration.Seconds(),
+		Count:           r.count,
+		Errors:          r.errors,
+		RetCodes:        map[int]int64{},
+		WindowSeconds:   len(r.buckets),
+	}
+	for code, count := range r.retCodes {
+		s.RetCodes[code] = count
+	}
+	if elapsed := now.Sub(r.lastTime).Seconds(); elapsed > 0 {
+		s.QPS = float64(r.count-r.lastCount) / elapsed
+	}
+	r.lastCount = r.count
+	r.lastTime = now
+
+	if h, count, errors := r.window(now); count > 0 {
+		s.P50Ms = h.CalcPercentile(50) * 1000
+		s.P90Ms = h.CalcPercentile(90) * 1000
+		s.P99Ms = h.CalcPercentile(99) * 1000
+		s.ErrorPercent = float64(errors) * 100 / float64(count)
+	}
+	return s
+}
+
+// windowMetrics returns the latencies, the number of requests and of failed ones over the rolling window
+func (r *LoadTestRecorder) windowMetrics() (*stats.HistogramData, int64, int64) {
+	r.rLock.Lock()
+	defer r.rLock.Unlock()
+	return r.window(time.Now())
+}
+
+// window must be called with the lock held
+func (r *LoadTestRecorder) window(now time.Time) (*stats.HistogramData, int64, int64) {
+	second := int(now.Sub(r.start) / time.Second)
+	window := stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution)
+	var count, errors int64
+	for _, b := range r.buckets {
+		if b.second < 0 || b.second <= second-len(r.buckets) {
+			continue
+		}
+		window.Transfer(b.durations.Clone())
+		count += b.count
+		errors += b.errors
+	}
+	return window.Export(), count, errors
+}
@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+)
+
+// RunLoadTest runs the load test with the given generator, adding the TLS proxy, the stop conditions,
+// the scenario and the stages around it as required by the options
+func RunLoadTest(ctx context.Context, gen models.LoadGeneratorInterface, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	runFunc := LoadTestFunc(gen.Run)
+	if opts.Cert != "" || opts.CACert != "" {
+		generatorFunc := runFunc
+		runFunc = func(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+			return ClientTLSLoadTest(ctx, opts, generatorFunc)
+		}
+	}
+	var stopper *loadTestStopper
+	if !opts.StopConditions.IsEmpty() {
+		stopper = newLoadTestStopper(opts.StopConditions)
+		generatorFunc := runFunc
+		// each endpoint of a scenario is checked on its own, the first one meeting a condition stops them all
+		runFunc = func(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+			return stopConditionsLoadTest(ctx, opts, generatorFunc, stopper)
+		}
+	}
+	if len(opts.Endpoints) > 0 {
+		generatorFunc := runFunc
+		runFunc = func(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+			return ScenarioLoadTest(ctx, opts, generatorFunc)
+		}
+	}
+	var (
+		resultsMap map[string]interface{}
+		result     *periodic.RunnerResults
+		err        error
+	)
+	if len(opts.Stages) > 0 {
+		resultsMap, result, err = StagedLoadTest(ctx, opts, runFunc)
+	} else {
+		resultsMap, result, err = runFunc(ctx, opts)
+	}
+	if err != nil || stopper == nil {
+		return resultsMap, result, err
+	}
+	if reason, ok := stopper.stopped(); ok {
+		markAborted(resultsMap, reason, time.Now())
+	}
+	return resultsMap, result, nil
+}
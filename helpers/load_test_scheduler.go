@@ -0,0 +1,366 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadTestScheduler runs the scheduled load tests when their cron expressions are due
+type LoadTestScheduler struct {
+	schedules map[uuid.UUID]*loadTestScheduleEntry
+	sLock     *sync.Mutex
+	persister *models.BitCaskLoadTestSchedulePersister
+	jobs      models.LoadTestJobTrackerInterface
+	// wake is signalled when the schedules change so the next activation is computed again
+	wake chan struct{}
+}
+
+type loadTestScheduleEntry struct {
+	schedule *models.LoadTestSchedule
+	cron     *CronSchedule
+	next     time.Time
+	running  bool
+}
+
+// NewLoadTestScheduler creates a new instance of LoadTestScheduler and loads the schedules persisted earlier,
+// the runs of the tests are followed on the job tracker
+func NewLoadTestScheduler(persister *models.BitCaskLoadTestSchedulePersister, jobs models.LoadTestJobTrackerInterface) *LoadTestScheduler {
+	s := &LoadTestScheduler{
+		schedules: map[uuid.UUID]*loadTestScheduleEntry{},
+		sLock:     &sync.Mutex{},
+		persister: persister,
+		jobs:      jobs,
+		wake:      make(chan struct{}, 1),
+	}
+	if persister == nil {
+		return s
+	}
+	schedules, err := persister.GetSchedules()
+	if err != nil {
+		logrus.Warnf("unable to load the persisted load test schedules: %v", err)
+		return s
+	}
+	now := time.Now()
+	legacy := false
+	for _, schedule := range schedules {
+		cron, err := ParseCronSchedule(schedule.Cron)
+		if err != nil {
+			logrus.Warnf("ignoring load test schedule %s: %v", schedule.ID, err)
+			continue
+		}
+		for _, run := range schedule.Runs {
+			if run.FinishedAt == nil {
+				// the process following this run is gone, like the job tracker does for its job
+				run.Status = models.LoadTestJobFailed
+				run.Message = "load test was interrupted by a Meshery restart"
+				run.FinishedAt = &now
+			}
+		}
+		if schedule.UserID == "" && !schedule.Paused {
+			logrus.Warnf("pausing load test schedule %s created before schedules had an owner, it has to be created again", schedule.ID)
+			schedule.Paused = true
+		}
+		legacy = legacy || schedule.UserID == ""
+		entry := &loadTestScheduleEntry{
+			schedule: schedule,
+			cron:     cron,
+		}
+		// the runs missed while Meshery was down are not caught up with
+		entry.reschedule(now)
+		s.schedules[schedule.ID] = entry
+		s.persist(schedule)
+	}
+	if legacy {
+		// the schedules without an owner were persisted along with the cookies of their creator
+		if err := persister.Compact(); err != nil {
+			logrus.Warnf("unable to drop the cookies of the load test schedules created before they had an owner: %v", err)
+		}
+	}
+	return s
+}
+
+// reschedule computes the next activation of the schedule after t
+func (e *loadTestScheduleEntry) reschedule(t time.Time) {
+	if e.schedule.Paused {
+		e.next = time.Time{}
+		e.schedule.NextRun = nil
+		return
+	}
+	e.next = e.cron.Next(t)
+	next := e.next
+	e.schedule.NextRun = &next
+}
+
+// AddSchedule registers a new schedule
+func (s *LoadTestScheduler) AddSchedule(ctx context.Context, schedule *models.LoadTestSchedule) error {
+	cron, err := ParseCronSchedule(schedule.Cron)
+	if err != nil {
+		return err
+	}
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	if _, ok := s.schedules[schedule.ID]; ok {
+		return fmt.Errorf("schedule with id %s already exists", schedule.ID)
+	}
+	now := time.Now()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	entry := &loadTestScheduleEntry{
+		schedule: schedule,
+		cron:     cron,
+	}
+	entry.reschedule(now)
+	s.schedules[schedule.ID] = entry
+	s.persist(schedule)
+	s.notify()
+	return nil
+}
+
+// GetSchedule retrieves a copy of the schedule with the given id
+func (s *LoadTestScheduler) GetSchedule(ctx context.Context, id uuid.UUID) (*models.LoadTestSchedule, error) {
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	entry, ok := s.schedules[id]
+	if !ok {
+		return nil, fmt.Errorf("schedule with id %s not found", id)
+	}
+	return copyLoadTestSchedule(entry.schedule), nil
+}
+
+// GetSchedules retrieves copies of all the schedules
+func (s *LoadTestScheduler) GetSchedules(ctx context.Context) ([]*models.LoadTestSchedule, error) {
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	schedules := []*models.LoadTestSchedule{}
+	for _, entry := range s.schedules {
+		schedules = append(schedules, copyLoadTestSchedule(entry.schedule))
+	}
+	return schedules, nil
+}
+
+// PauseSchedule pauses or resumes the schedule, a resumed schedule runs at its next activation from now
+func (s *LoadTestScheduler) PauseSchedule(ctx context.Context, id uuid.UUID, paused bool) error {
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	entry, ok := s.schedules[id]
+	if !ok {
+		return fmt.Errorf("schedule with id %s not found", id)
+	}
+	if entry.schedule.Paused == paused {
+		return nil
+	}
+	now := time.Now()
+	entry.schedule.Paused = paused
+	entry.schedule.UpdatedAt = now
+	entry.reschedule(now)
+	s.persist(entry.schedule)
+	s.notify()
+	return nil
+}
+
+// DeleteSchedule removes the schedule, a run in progress carries on
+func (s *LoadTestScheduler) DeleteSchedule(ctx context.Context, id uuid.UUID) error {
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	if _, ok := s.schedules[id]; !ok {
+		return fmt.Errorf("schedule with id %s not found", id)
+	}
+	delete(s.schedules, id)
+	if s.persister != nil {
+		if err := s.persister.DeleteSchedule(id); err != nil {
+			return err
+		}
+	}
+	s.notify()
+	return nil
+}
+
+// Run launches the tests of the schedules when they are due until the context is cancelled
+func (s *LoadTestScheduler) Run(ctx context.Context, launch models.LoadTestLaunchFunc) {
+	for {
+		var (
+			due  []uuid.UUID
+			next time.Time
+		)
+		s.sLock.Lock()
+		now := time.Now()
+		for id, entry := range s.schedules {
+			if entry.next.IsZero() {
+				continue
+			}
+			if !entry.next.After(now) {
+				due = append(due, id)
+				entry.reschedule(now)
+				s.persist(entry.schedule)
+			}
+			if next.IsZero() || entry.next.Before(next) {
+				next = entry.next
+			}
+		}
+		s.sLock.Unlock()
+
+		for _, id := range due {
+			go s.runSchedule(ctx, launch, id)
+		}
+
+		var (
+			timer  *time.Timer
+			timerC <-chan time.Time
+		)
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			timerC = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-timerC:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// runSchedule launches the test of the schedule and records the outcome of its job in the history of the schedule
+func (s *LoadTestScheduler) runSchedule(ctx context.Context, launch models.LoadTestLaunchFunc, id uuid.UUID) {
+	run := &models.LoadTestScheduleRun{
+		StartedAt: time.Now(),
+	}
+	s.sLock.Lock()
+	entry, ok := s.schedules[id]
+	if !ok {
+		s.sLock.Unlock()
+		return
+	}
+	if entry.running {
+		logrus.Warnf("skipping the run of load test schedule %s as the previous one is still going", id)
+		run.Skipped = true
+		run.FinishedAt = &run.StartedAt
+		s.addRun(entry, run)
+		s.sLock.Unlock()
+		return
+	}
+	entry.running = true
+	schedule := copyLoadTestSchedule(entry.schedule)
+	s.sLock.Unlock()
+	defer func() {
+		s.sLock.Lock()
+		entry.running = false
+		s.sLock.Unlock()
+	}()
+
+	logrus.Infof("running load test schedule %s", id)
+	jobID, err := launch(ctx, schedule)
+	if err != nil {
+		logrus.Errorf("unable to run load test schedule %s: %v", id, err)
+		s.finishRun(entry, run, models.LoadTestJobFailed, "", err.Error())
+		return
+	}
+	s.sLock.Lock()
+	run.JobID = jobID
+	run.Status = models.LoadTestJobRunning
+	s.addRun(entry, run)
+	s.sLock.Unlock()
+
+	_, sub, unsubscribe, err := s.jobs.Subscribe(ctx, jobID)
+	if err != nil {
+		s.finishRun(entry, run, models.LoadTestJobFailed, "", err.Error())
+		return
+	}
+	defer unsubscribe()
+	// the subscription is closed once the job is over
+WAIT:
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-sub:
+			if !ok {
+				break WAIT
+			}
+		}
+	}
+	job, err := s.jobs.GetJob(ctx, jobID)
+	if err != nil {
+		s.finishRun(entry, run, models.LoadTestJobFailed, "", err.Error())
+		return
+	}
+	var msg string
+	if len(job.Messages) > 0 && job.Status != models.LoadTestJobCompleted {
+		msg = job.Messages[len(job.Messages)-1].Message
+	}
+	s.finishRun(entry, run, job.Status, job.ResultID, msg)
+}
+
+// addRun adds the run to the history of the schedule, the caller holds the lock
+func (s *LoadTestScheduler) addRun(entry *loadTestScheduleEntry, run *models.LoadTestScheduleRun) {
+	schedule := entry.schedule
+	schedule.Runs = append(schedule.Runs, run)
+	if len(schedule.Runs) > models.MaxLoadTestScheduleRuns {
+		schedule.Runs = schedule.Runs[len(schedule.Runs)-models.MaxLoadTestScheduleRuns:]
+	}
+	schedule.UpdatedAt = time.Now()
+	if _, ok := s.schedules[schedule.ID]; ok {
+		s.persist(schedule)
+	}
+}
+
+// finishRun records the outcome of the run, the run is added to the history when it was not yet
+func (s *LoadTestScheduler) finishRun(entry *loadTestScheduleEntry, run *models.LoadTestScheduleRun, status models.LoadTestJobStatus, resultID, msg string) {
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	now := time.Now()
+	run.Status = status
+	run.ResultID = resultID
+	run.Message = msg
+	run.FinishedAt = &now
+	if run.JobID == uuid.Nil {
+		s.addRun(entry, run)
+		return
+	}
+	entry.schedule.UpdatedAt = now
+	if _, ok := s.schedules[entry.schedule.ID]; ok {
+		s.persist(entry.schedule)
+	}
+}
+
+// notify wakes the run loop up, the caller holds the lock
+func (s *LoadTestScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *LoadTestScheduler) persist(schedule *models.LoadTestSchedule) {
+	if s.persister == nil {
+		return
+	}
+	if err := s.persister.WriteSchedule(schedule); err != nil {
+		logrus.Warnf("unable to persist load test schedule %s: %v", schedule.ID, err)
+	}
+}
+
+func copyLoadTestSchedule(schedule *models.LoadTestSchedule) *models.LoadTestSchedule {
+	scheduleCopy := *schedule
+	scheduleCopy.Runs = make([]*models.LoadTestScheduleRun, len(schedule.Runs))
+	for i, run := range schedule.Runs {
+		runCopy := *run
+		scheduleCopy.Runs[i] = &runCopy
+	}
+	if schedule.NextRun != nil {
+		next := *schedule.NextRun
+		scheduleCopy.NextRun = &next
+	}
+	return &scheduleCopy
+}
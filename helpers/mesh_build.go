@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MeshBuild - represents the versions and the configuration of a service mesh found in the cluster
+type MeshBuild struct {
+	Mesh       string
+	MeshBuild  string
+	ProxyBuild string
+	Config     *models.MeshConfig
+}
+
+// meshBuildImages - represents the images the builds of a service mesh are read from
+type meshBuildImages struct {
+	controlPlane []string
+	proxy        []string
+}
+
+// NOT TO BE UPDATED at runtime
+var meshBuildImagesByMesh = map[string]*meshBuildImages{
+	"Istio": {
+		controlPlane: []string{"docker.io/istio/pilot", "docker.io/istio/galley", "docker.io/istio/citadel"},
+		proxy:        []string{"docker.io/istio/proxyv2"},
+	},
+	"Linkerd": {
+		controlPlane: []string{"gcr.io/linkerd-io/controller"},
+		proxy:        []string{"gcr.io/linkerd-io/proxy"},
+	},
+	"Consul": {
+		controlPlane: []string{"hashicorp/consul-k8s"},
+		proxy:        []string{"envoyproxy/envoy"},
+	},
+	"Network Service Mesh": {
+		controlPlane: []string{"docker.io/networkservicemesh/nsmd"},
+	},
+}
+
+// istioMeshConfig - represents the part of the Istio mesh config in the istio ConfigMap which is used
+type istioMeshConfig struct {
+	EnableAutoMtls      bool `yaml:"enableAutoMtls"`
+	DisablePolicyChecks bool `yaml:"disablePolicyChecks"`
+	DefaultConfig       struct {
+		Concurrency int `yaml:"concurrency"`
+	} `yaml:"defaultConfig"`
+}
+
+// InspectMesh infers the builds and the configuration of the service mesh of a test from the deployments
+// found by ScanKubernetes, nil is returned when the mesh is not among them
+func InspectMesh(kubeconfig []byte, contextName, meshName string, detectedMeshes map[string][]v1.Deployment) *MeshBuild {
+	mesh := ""
+	for name := range detectedMeshes {
+		if meshName != "" && strings.Contains(strings.ToLower(meshName), strings.ToLower(name)) {
+			mesh = name
+			break
+		}
+	}
+	if mesh == "" {
+		if len(detectedMeshes) != 1 {
+			return nil
+		}
+		for name := range detectedMeshes {
+			mesh = name
+		}
+	}
+	deployments := append([]v1.Deployment{}, detectedMeshes[mesh]...)
+	// deterministic when the deployments do not agree on the versions
+	sort.Slice(deployments, func(i, j int) bool {
+		return deployments[i].Namespace+"/"+deployments[i].Name < deployments[j].Namespace+"/"+deployments[j].Name
+	})
+
+	b := &MeshBuild{
+		Mesh:   mesh,
+		Config: &models.MeshConfig{},
+	}
+	if images, ok := meshBuildImagesByMesh[mesh]; ok {
+		b.MeshBuild = imageTag(deployments, images.controlPlane)
+		b.ProxyBuild = imageTag(deployments, images.proxy)
+	}
+	switch mesh {
+	case "Istio":
+		b.Config.MeshTelemetryEnabled = hasDeployment(deployments, "istio-telemetry")
+		b.Config.MeshPolicyEnabled = hasDeployment(deployments, "istio-policy")
+		if err := inspectIstioConfig(kubeconfig, contextName, deployments, b.Config); err != nil {
+			logrus.Warnf("unable to read the configuration of Istio: %v", err)
+		}
+	case "Linkerd":
+		// identities are issued to the proxies for mTLS by the identity service
+		b.Config.MtlsEnabled = hasDeployment(deployments, "linkerd-identity")
+		b.Config.MeshTelemetryEnabled = hasDeployment(deployments, "linkerd-prometheus")
+	}
+	return b
+}
+
+// inspectIstioConfig reads the mesh config and the default mesh policy of Istio
+func inspectIstioConfig(kubeconfig []byte, contextName string, deployments []v1.Deployment, config *models.MeshConfig) error {
+	namespace := "istio-system"
+	for _, d := range deployments {
+		if d.Name == "istiod" || d.Name == "istio-pilot" {
+			namespace = d.Namespace
+			break
+		}
+	}
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get("istio", metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "unable to get the istio ConfigMap")
+	}
+	meshConfig := &istioMeshConfig{}
+	if err := yaml.Unmarshal([]byte(cm.Data["mesh"]), meshConfig); err != nil {
+		return errors.Wrap(err, "unable to parse the mesh config")
+	}
+	config.MtlsEnabled = meshConfig.EnableAutoMtls
+	config.ProxyConcurrency = meshConfig.DefaultConfig.Concurrency
+	config.MeshPolicyEnabled = config.MeshPolicyEnabled && !meshConfig.DisablePolicyChecks
+
+	if config.MtlsEnabled {
+		return nil
+	}
+	istioClient, err := getIstioClient(kubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+	policy, err := istioClient.AuthenticationV1alpha1().MeshPolicies().Get("default", metav1.GetOptions{})
+	if err != nil {
+		// there is no mesh wide policy from Istio 1.5 on
+		logrus.Debugf("unable to get the default mesh policy: %v", err)
+		return nil
+	}
+	for _, peer := range policy.Spec.GetPeers() {
+		if peer.GetMtls() != nil {
+			config.MtlsEnabled = true
+		}
+	}
+	return nil
+}
+
+func hasDeployment(deployments []v1.Deployment, name string) bool {
+	for _, d := range deployments {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// imageTag returns the tag of the first of the images which is used by the deployments
+func imageTag(deployments []v1.Deployment, imageNames []string) string {
+	for _, d := range deployments {
+		for _, containers := range [][]corev1.Container{d.Spec.Template.Spec.Containers, d.Spec.Template.Spec.InitContainers} {
+			for _, cont := range containers {
+				for _, imageName := range imageNames {
+					if strings.HasPrefix(cont.Image, imageName) || strings.Contains(cont.Image, imageName+":") {
+						return tagOf(cont.Image)
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// tagOf returns the tag or the digest of the image, images without any being the latest
+func tagOf(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
@@ -0,0 +1,239 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	promModel "github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/apps/v1"
+)
+
+// meshComponentQueries - represents how a component of a service mesh is found in Prometheus,
+// pods is the selector of its containers in the cAdvisor metrics
+type meshComponentQueries struct {
+	pods string
+	rps  string
+	bps  string
+}
+
+// meshMetricsQueries - represents the queries of the SMPS metrics of a service mesh, the components
+// which the mesh does not have are left nil
+type meshMetricsQueries struct {
+	ingressGateway *meshComponentQueries
+	sidecars       *meshComponentQueries
+	telemetry      *meshComponentQueries
+	policy         *meshComponentQueries
+	controlPlane   *meshComponentQueries
+
+	// of the control plane
+	services        string
+	virtualServices string
+	proxies         string
+	ldsLatencyMs    string
+	cdsLatencyMs    string
+}
+
+// istioPushLatencyMs is the mean time taken by pilot to push a type of xDS config to the proxies
+const istioPushLatencyMs = `sum(rate(pilot_xds_push_time_sum{type="%[1]s"}[1m])) / sum(rate(pilot_xds_push_time_count{type="%[1]s"}[1m])) * 1000`
+
+var meshMetricsQueriesByMesh = map[string]*meshMetricsQueries{
+	"Istio": {
+		ingressGateway: &meshComponentQueries{
+			pods: `namespace="istio-system", pod=~"istio-ingressgateway-.*"`,
+			rps:  `sum(rate(istio_requests_total{reporter="source", source_workload="istio-ingressgateway"}[1m]))`,
+			bps:  `sum(rate({__name__=~"istio_(request|response)_bytes_sum", reporter="source", source_workload="istio-ingressgateway"}[1m]))`,
+		},
+		sidecars: &meshComponentQueries{
+			pods: `container="istio-proxy", pod!~"istio-(ingress|egress)gateway-.*"`,
+			rps:  `sum(rate(istio_requests_total{reporter="destination"}[1m]))`,
+			bps:  `sum(rate({__name__=~"istio_(request|response)_bytes_sum", reporter="destination"}[1m]))`,
+		},
+		// mixer, up to Istio 1.4
+		telemetry: &meshComponentQueries{
+			pods: `namespace="istio-system", pod=~"istio-telemetry-.*"`,
+			rps:  `sum(rate(grpc_server_handled_total{grpc_service="istio.mixer.v1.Mixer", grpc_method="Report"}[1m]))`,
+		},
+		policy: &meshComponentQueries{
+			pods: `namespace="istio-system", pod=~"istio-policy-.*"`,
+			rps:  `sum(rate(grpc_server_handled_total{grpc_service="istio.mixer.v1.Mixer", grpc_method="Check"}[1m]))`,
+		},
+		controlPlane: &meshComponentQueries{
+			pods: `namespace="istio-system", pod=~"istiod-.*|istio-pilot-.*|istio-galley-.*|istio-citadel-.*|istio-sidecar-injector-.*"`,
+		},
+		services:        `max(pilot_services)`,
+		virtualServices: `max(pilot_virt_services)`,
+		proxies:         `sum(pilot_xds)`,
+		ldsLatencyMs:    fmt.Sprintf(istioPushLatencyMs, "lds"),
+		cdsLatencyMs:    fmt.Sprintf(istioPushLatencyMs, "cds"),
+	},
+	"Linkerd": {
+		sidecars: &meshComponentQueries{
+			pods: `container="linkerd-proxy", namespace!="linkerd"`,
+			rps:  `sum(rate(request_total{direction="inbound", namespace!="linkerd"}[1m]))`,
+			bps:  `sum(rate({__name__=~"tcp_(read|write)_bytes_total", direction="inbound", namespace!="linkerd"}[1m]))`,
+		},
+		telemetry: &meshComponentQueries{
+			pods: `namespace="linkerd", pod=~"linkerd-(prometheus|grafana)-.*"`,
+		},
+		controlPlane: &meshComponentQueries{
+			pods: `namespace="linkerd", pod!~"linkerd-(prometheus|grafana)-.*"`,
+		},
+		proxies: `count(count by (pod) (container_memory_working_set_bytes{container="linkerd-proxy", namespace!="linkerd"}))`,
+	},
+}
+
+// MeshForMetrics returns the name of the service mesh whose metrics can be collected for a test against the given mesh,
+// the detected meshes are used when the name does not tell and empty is returned when there is no such mesh
+func MeshForMetrics(meshName string, detectedMeshes map[string][]v1.Deployment) string {
+	for mesh := range meshMetricsQueriesByMesh {
+		if strings.Contains(strings.ToLower(meshName), strings.ToLower(mesh)) {
+			return mesh
+		}
+	}
+	found := ""
+	for mesh := range detectedMeshes {
+		if _, ok := meshMetricsQueriesByMesh[mesh]; ok {
+			if found != "" {
+				return ""
+			}
+			found = mesh
+		}
+	}
+	return found
+}
+
+// CollectMeshMetrics computes the SMPS metrics of the service mesh from Prometheus, as their means over the window,
+// the components which are not found in Prometheus are left nil
+func CollectMeshMetrics(ctx context.Context, prom *models.PrometheusClient, promURL, mesh string, start, end time.Time) (*models.Metrics, error) {
+	queries, ok := meshMetricsQueriesByMesh[mesh]
+	if !ok {
+		return nil, fmt.Errorf("collecting the metrics of %s is not supported", mesh)
+	}
+	c := &meshMetricsCollector{
+		ctx:     ctx,
+		prom:    prom,
+		promURL: promURL,
+		start:   start,
+		end:     end,
+		step:    prom.ComputeStep(ctx, start, end),
+	}
+	metrics := &models.Metrics{}
+	if q := queries.ingressGateway; q != nil {
+		count, cpu, mem, rps, bps, found := c.component(q)
+		if found {
+			metrics.IngressGateway = &models.IngressGateway{Count: count, CPUMCores: cpu, MemMb: mem, Rps: rps, Bps: bps}
+		}
+	}
+	if q := queries.sidecars; q != nil {
+		count, cpu, mem, rps, bps, found := c.component(q)
+		if found {
+			metrics.Sidecars = &models.Sidecars{Count: count, CPUMCores: cpu, MemMb: mem, Rps: rps, Bps: bps}
+		}
+	}
+	if q := queries.telemetry; q != nil {
+		count, cpu, mem, rps, _, found := c.component(q)
+		if found {
+			metrics.MeshTelemetry = &models.MeshTelemetry{Count: count, CPUMCores: cpu, MemMb: mem, Rps: rps}
+		}
+	}
+	if q := queries.policy; q != nil {
+		count, cpu, mem, rps, _, found := c.component(q)
+		if found {
+			metrics.MeshPolicy = &models.MeshPolicy{Count: count, CPUMCores: cpu, MemMb: mem, Rps: rps}
+		}
+	}
+	if q := queries.controlPlane; q != nil {
+		count, cpu, mem, _, _, found := c.component(q)
+		if found {
+			metrics.MeshControlPlane = &models.MeshControlPlane{
+				Count:           count,
+				CPUMCores:       cpu,
+				MemMb:           mem,
+				Services:        int(math.Round(c.mean(queries.services))),
+				VirtualServices: int(math.Round(c.mean(queries.virtualServices))),
+				Sidecars:        int(math.Round(c.mean(queries.proxies))),
+				LdsLatencyMs:    c.mean(queries.ldsLatencyMs),
+				CdsLatencyMs:    c.mean(queries.cdsLatencyMs),
+			}
+		}
+	}
+	if c.err != nil && c.found == 0 {
+		return nil, errors.Wrapf(c.err, "unable to collect the metrics of %s", mesh)
+	}
+	if c.found == 0 {
+		return nil, fmt.Errorf("no metrics of %s were found in Prometheus", mesh)
+	}
+	return metrics, nil
+}
+
+// meshMetricsCollector runs the range queries of the window, a query which fails or has no data counts as zero
+type meshMetricsCollector struct {
+	ctx     context.Context
+	prom    *models.PrometheusClient
+	promURL string
+	start   time.Time
+	end     time.Time
+	step    time.Duration
+
+	found int
+	err   error
+}
+
+// component returns the number of pods of the component, their cpu in mCores, memory in MB and the rps and bps
+func (c *meshMetricsCollector) component(q *meshComponentQueries) (count int, cpu, mem, rps, bps float64, found bool) {
+	// the pod level cgroups are left out so that the containers are not counted twice
+	selector := q.pods + `, container!="", container!="POD"`
+	before := c.found
+	count = int(math.Round(c.mean(`count(count by (pod) (container_memory_working_set_bytes{` + selector + `}))`)))
+	if c.found == before {
+		// the component is not deployed
+		return 0, 0, 0, 0, 0, false
+	}
+	cpu = c.mean(`sum(rate(container_cpu_usage_seconds_total{` + selector + `}[1m])) * 1000`)
+	mem = c.mean(`sum(container_memory_working_set_bytes{` + selector + `}) / 1024 / 1024`)
+	rps = c.mean(q.rps)
+	bps = c.mean(q.bps)
+	return count, cpu, mem, rps, bps, true
+}
+
+// mean returns the mean of the samples of the query over the window
+func (c *meshMetricsCollector) mean(query string) float64 {
+	// Prometheus is not queried again when it failed before returning anything
+	if query == "" || (c.err != nil && c.found == 0) {
+		return 0
+	}
+	v, err := c.prom.QueryRangeUsingClient(c.ctx, c.promURL, query, c.start, c.end, c.step)
+	if err != nil {
+		c.err = err
+		return 0
+	}
+	matrix, ok := v.(promModel.Matrix)
+	if !ok {
+		logrus.Warnf("unexpected result type %s for query: %s", v.Type(), query)
+		return 0
+	}
+	var sum float64
+	var n int
+	for _, stream := range matrix {
+		for _, s := range stream.Values {
+			f := float64(s.Value)
+			// rates are NaN when nothing happened
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				continue
+			}
+			sum += f
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	c.found++
+	return sum / float64(n)
+}
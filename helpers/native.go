@@ -0,0 +1,296 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// nativeVersion is reported as the version of the generator in the results
+const nativeVersion = "meshery-native"
+
+// NativeLoadTest runs an open model HTTP load test: requests start at the times given by the arrival
+// of the options regardless of how long the previous ones took, and the latency of a request is measured
+// from the time it was due, so that a slow service is not hidden by coordinated omission.
+// HTTPNumThreads caps the number of requests in flight, requests due while all of them are busy are
+// sent as soon as one frees up, their latency includes the wait.
+func NativeLoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if opts.HTTPQPS <= 0 {
+		err := errors.New("the native load generator needs a qps")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	rURL := strings.TrimSpace(opts.URL)
+	if _, err := newNativeRequest(ctx, opts, rURL); err != nil {
+		err = errors.Wrap(err, "error while building the request")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	threads := opts.HTTPNumThreads
+	if threads < 1 {
+		threads = 1
+	}
+	client := &http.Client{
+		Timeout: fhttp.HTTPReqTimeOutDefaultValue,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        threads,
+			MaxIdleConnsPerHost: threads,
+			DisableKeepAlives:   opts.DisableKeepAlive,
+			DisableCompression:  !opts.Compression,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: opts.IsInsecure}, // nolint: gosec
+		},
+	}
+	if opts.HTTPReqTimeout > 0 {
+		client.Timeout = opts.HTTPReqTimeout
+	}
+	defer client.CloseIdleConnections()
+
+	var socketCount int64
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				atomic.AddInt64(&socketCount, 1)
+			}
+		},
+	}
+	traceCtx := httptrace.WithClientTrace(ctx, trace)
+
+	start := time.Now()
+	schedule := newArrivalSchedule(opts.Arrival, opts.HTTPQPS, start, start.Add(opts.Duration))
+	workers := make([]*nativeWorker, threads)
+	wg := &sync.WaitGroup{}
+	for i := range workers {
+		workers[i] = newNativeWorker()
+		wg.Add(1)
+		go func(w *nativeWorker) {
+			defer wg.Done()
+			w.run(traceCtx, client, schedule, opts, rURL)
+		}(workers[i])
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	if err := loadTestCanceled(ctx); err != nil {
+		err = errors.Wrap(err, "error while running tests")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	res := &fhttp.HTTPRunnerResults{
+		RunnerResults: periodic.RunnerResults{
+			RunType:           "HTTP",
+			Labels:            opts.Name + " -_- " + rURL,
+			StartTime:         start,
+			RequestedQPS:      fmt.Sprintf("%.9g", opts.HTTPQPS),
+			RequestedDuration: opts.Duration.String(),
+			ActualDuration:    elapsed,
+			NumThreads:        threads,
+			Version:           nativeVersion,
+		},
+		RetCodes:    map[int]int64{},
+		URL:         rURL,
+		SocketCount: int(atomic.LoadInt64(&socketCount)),
+	}
+	durations := workers[0].durations
+	sizes := workers[0].sizes
+	headerSizes := workers[0].headerSizes
+	for i, w := range workers {
+		if i > 0 {
+			durations.Transfer(w.durations)
+			sizes.Transfer(w.sizes)
+			headerSizes.Transfer(w.headerSizes)
+		}
+		for code, count := range w.retCodes {
+			res.RetCodes[code] += count
+		}
+	}
+	res.DurationHistogram = durations.Export().CalcPercentiles(defaultPercentiles)
+	res.Sizes = sizes.Export().CalcPercentiles(defaultPercentiles)
+	res.HeaderSizes = headerSizes.Export().CalcPercentiles(defaultPercentiles)
+	res.ActualQPS = float64(res.DurationHistogram.Count) / elapsed.Seconds()
+	logrus.Debugf("original version of the test: %+#v", res)
+
+	resultsMap, err := toResultsMap(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	resultsMap["Arrival"] = string(schedule.arrival)
+	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
+	return resultsMap, res.Result(), nil
+}
+
+// newNativeRequest builds a request of the load test, sent as a POST when there is a body and no method
+func newNativeRequest(ctx context.Context, opts *models.LoadTestOptions, rURL string) (*http.Request, error) {
+	method := strings.ToUpper(opts.Method)
+	if method == "" {
+		method = http.MethodGet
+		if len(opts.Payload) > 0 || opts.ContentType != "" {
+			method = http.MethodPost
+		}
+	}
+	var body io.Reader
+	if len(opts.Payload) > 0 {
+		body = bytes.NewReader(opts.Payload)
+	}
+	req, err := http.NewRequest(method, rURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range opts.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if opts.ContentType != "" {
+		req.Header.Set("Content-Type", opts.ContentType)
+	}
+	if opts.UserCredentials != "" {
+		creds := strings.SplitN(opts.UserCredentials, ":", 2)
+		if len(creds) != 2 {
+			return nil, errors.New("user credentials must be user:password")
+		}
+		req.SetBasicAuth(creds[0], creds[1])
+	}
+	if opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.BearerToken)
+	}
+	return req, nil
+}
+
+// arrivalSchedule hands out the times at which the requests of an open model load test are due
+type arrivalSchedule struct {
+	arrival  models.LoadTestArrival
+	interval time.Duration
+	next     time.Time
+	end      time.Time
+	rnd      *rand.Rand
+	sLock    *sync.Mutex
+}
+
+func newArrivalSchedule(arrival models.LoadTestArrival, qps float64, start, end time.Time) *arrivalSchedule {
+	if arrival == "" {
+		arrival = models.ConstantArrival
+	}
+	return &arrivalSchedule{
+		arrival:  arrival,
+		interval: time.Duration(float64(time.Second) / qps),
+		next:     start,
+		end:      end,
+		rnd:      rand.New(rand.NewSource(start.UnixNano())),
+		sLock:    &sync.Mutex{},
+	}
+}
+
+// take returns the time the next request is due, false once the test is over
+func (s *arrivalSchedule) take() (time.Time, bool) {
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	if !s.next.Before(s.end) {
+		return time.Time{}, false
+	}
+	due := s.next
+	if s.arrival == models.PoissonArrival {
+		s.next = s.next.Add(time.Duration(s.rnd.ExpFloat64() * float64(s.interval)))
+	} else {
+		s.next = s.next.Add(s.interval)
+	}
+	return due, true
+}
+
+// nativeWorker sends requests as they become due and records their outcome
+type nativeWorker struct {
+	durations   *stats.Histogram
+	sizes       *stats.Histogram
+	headerSizes *stats.Histogram
+	retCodes    map[int]int64
+}
+
+func newNativeWorker() *nativeWorker {
+	return &nativeWorker{
+		durations:   stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution),
+		sizes:       stats.NewHistogram(0, 100),
+		headerSizes: stats.NewHistogram(0, 5),
+		retCodes:    map[int]int64{},
+	}
+}
+
+func (w *nativeWorker) run(ctx context.Context, client *http.Client, schedule *arrivalSchedule, opts *models.LoadTestOptions, rURL string) {
+	recorder := loadTestRecorderFrom(ctx)
+	for {
+		due, ok := schedule.take()
+		if !ok {
+			return
+		}
+		if wait := time.Until(due); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+		code, size, headerSize := w.fetch(ctx, client, opts, rURL)
+		if ctx.Err() != nil {
+			// the request was cut short by the end of the test
+			return
+		}
+		latency := time.Since(due)
+		recorder.Record(code, latency)
+		w.durations.Record(latency.Seconds())
+		w.sizes.Record(float64(size))
+		w.headerSizes.Record(float64(headerSize))
+		w.retCodes[code]++
+	}
+}
+
+// fetch sends one request, the code is -1 when no response could be read like for fortio
+func (w *nativeWorker) fetch(ctx context.Context, client *http.Client, opts *models.LoadTestOptions, rURL string) (int, int64, int) {
+	req, err := newNativeRequest(ctx, opts, rURL)
+	if err != nil {
+		return -1, 0, 0
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		logrus.Debugf("error while sending the request: %v", err)
+		return -1, 0, 0
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	size, err := io.Copy(ioutil.Discard, resp.Body)
+	if err != nil {
+		logrus.Debugf("error while reading the response: %v", err)
+		return -1, size, 0
+	}
+	// net/http does not expose the raw headers, their size is estimated from the parsed ones
+	headerSize := len(resp.Proto) + len(resp.Status) + 4
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			headerSize += len(k) + len(v) + 4
+		}
+	}
+	return resp.StatusCode, size, headerSize
+}
@@ -0,0 +1,488 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+)
+
+// comparisonConfidence is the confidence level of the tests used to flag regressions
+const comparisonConfidence = 0.95
+
+// tTable95 holds the one sided 95% quantiles of the Student t distribution for 1 to 30 degrees of freedom
+var tTable95 = []float64{
+	6.314, 2.920, 2.353, 2.132, 2.015, 1.943, 1.895, 1.860, 1.833, 1.812,
+	1.796, 1.782, 1.771, 1.761, 1.753, 1.746, 1.740, 1.734, 1.729, 1.725,
+	1.721, 1.717, 1.714, 1.711, 1.708, 1.706, 1.703, 1.701, 1.699, 1.697,
+}
+
+// comparedResult holds what is needed from a result to compare it
+type comparedResult struct {
+	summary       *models.ResultSummary
+	histogram     *stats.HistogramData
+	serverMetrics map[string]float64
+}
+
+// CompareResults compares each candidate against the baseline, the baseline results are pooled together.
+//
+// A metric is flagged as a regression when it got worse by more than tolerance percent and the change is
+// statistically significant at 95%:
+//   - with at least 3 baseline runs, the candidate has to fall outside the one sided prediction interval
+//     of the baseline runs, which accounts for the noise between runs
+//   - otherwise latencies are compared with a Mann-Whitney U test on the histograms when they have buckets
+//     and the error rates with a two proportion z-test
+func CompareResults(baseline, candidates []*models.MesheryResult, tolerance float64) (*models.ResultComparison, error) {
+	if len(baseline) == 0 || len(candidates) == 0 {
+		return nil, errors.New("a baseline and at least one candidate are needed to compare results")
+	}
+	if tolerance < 0 {
+		return nil, fmt.Errorf("invalid tolerance: %g", tolerance)
+	}
+	baseRuns := make([]*comparedResult, len(baseline))
+	for i, r := range baseline {
+		cr, err := newComparedResult(r)
+		if err != nil {
+			return nil, err
+		}
+		baseRuns[i] = cr
+	}
+	pooled := poolComparedResults(baseRuns)
+
+	comparison := &models.ResultComparison{
+		Baseline:     pooled.summary,
+		BaselineRuns: make([]*models.ResultSummary, len(baseRuns)),
+		Comparisons:  make([]*models.ResultDiff, len(candidates)),
+		Tolerance:    tolerance,
+		Confidence:   comparisonConfidence,
+	}
+	for i, r := range baseRuns {
+		comparison.BaselineRuns[i] = r.summary
+	}
+	for i, c := range candidates {
+		cr, err := newComparedResult(c)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Comparisons[i] = diffResults(pooled, baseRuns, cr, tolerance)
+	}
+	return comparison, nil
+}
+
+func newComparedResult(m *models.MesheryResult) (*comparedResult, error) {
+	bd, err := json.Marshal(m.Result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read result %s", m.ID)
+	}
+	res := struct {
+		RunType           string
+		StartTime         time.Time
+		ActualQPS         float64
+		DurationHistogram *stats.HistogramData
+		RetCodes          map[string]int64
+		MeshBuild         string `json:"mesh_build"`
+		ProxyBuild        string `json:"proxy_build"`
+	}{}
+	if err = json.Unmarshal(bd, &res); err != nil {
+		return nil, errors.Wrapf(err, "unable to read result %s", m.ID)
+	}
+	if res.DurationHistogram == nil {
+		return nil, fmt.Errorf("result %s has no latency histogram", m.ID)
+	}
+	s := &models.ResultSummary{
+		ID:         m.ID,
+		Name:       m.Name,
+		Mesh:       m.Mesh,
+		MeshBuild:  res.MeshBuild,
+		ProxyBuild: res.ProxyBuild,
+		StartTime:  res.StartTime,
+		Runs:       1,
+		Count:      res.DurationHistogram.Count,
+		QPS:        res.ActualQPS,
+		RetCodes:   map[string]int64{},
+	}
+	var failed, total int64
+	for code, count := range res.RetCodes {
+		s.RetCodes[code] += count
+		total += count
+		if !models.IsSuccessCode(res.RunType, code) {
+			failed += count
+		}
+	}
+	if total > 0 {
+		s.ErrorPercent = float64(failed) * 100 / float64(total)
+	}
+	fillLatencies(s, res.DurationHistogram)
+	return &comparedResult{
+		summary:       s,
+		histogram:     res.DurationHistogram,
+		serverMetrics: serverMetricMeans(m.ServerMetrics),
+	}, nil
+}
+
+func fillLatencies(s *models.ResultSummary, h *stats.HistogramData) {
+	s.AvgMs = h.Avg * 1000
+	s.P50Ms = models.PercentileMs(h, 50)
+	s.P90Ms = models.PercentileMs(h, 90)
+	s.P99Ms = models.PercentileMs(h, 99)
+}
+
+// poolComparedResults merges several runs into one, the qps is the average of the runs
+func poolComparedResults(runs []*comparedResult) *comparedResult {
+	if len(runs) == 1 {
+		return runs[0]
+	}
+	s := &models.ResultSummary{
+		Name:       runs[0].summary.Name,
+		Mesh:       runs[0].summary.Mesh,
+		MeshBuild:  runs[0].summary.MeshBuild,
+		ProxyBuild: runs[0].summary.ProxyBuild,
+		Runs:       len(runs),
+		RetCodes:   map[string]int64{},
+	}
+	hs := make([]*stats.HistogramData, len(runs))
+	metrics := map[string][]float64{}
+	var failed, total float64
+	for i, r := range runs {
+		hs[i] = r.histogram
+		s.QPS += r.summary.QPS / float64(len(runs))
+		for code, count := range r.summary.RetCodes {
+			s.RetCodes[code] += count
+			total += float64(count)
+		}
+		failed += r.summary.ErrorPercent / 100 * totalCount(r.summary.RetCodes)
+		// the builds are only reported when all the runs agree on them
+		if r.summary.MeshBuild != s.MeshBuild {
+			s.MeshBuild = ""
+		}
+		if r.summary.ProxyBuild != s.ProxyBuild {
+			s.ProxyBuild = ""
+		}
+		if s.StartTime.IsZero() || r.summary.StartTime.Before(s.StartTime) {
+			s.StartTime = r.summary.StartTime
+		}
+		for k, v := range r.serverMetrics {
+			metrics[k] = append(metrics[k], v)
+		}
+	}
+	if total > 0 {
+		s.ErrorPercent = failed * 100 / total
+	}
+	merged := MergeHistogramData(defaultPercentiles, hs...)
+	s.Count = merged.Count
+	fillLatencies(s, merged)
+
+	serverMetrics := map[string]float64{}
+	for k, vs := range metrics {
+		mean, _ := meanStdDev(vs)
+		serverMetrics[k] = mean
+	}
+	return &comparedResult{
+		summary:       s,
+		histogram:     merged,
+		serverMetrics: serverMetrics,
+	}
+}
+
+func totalCount(retCodes map[string]int64) float64 {
+	var total int64
+	for _, count := range retCodes {
+		total += count
+	}
+	return float64(total)
+}
+
+func diffResults(base *comparedResult, baseRuns []*comparedResult, cand *comparedResult, tolerance float64) *models.ResultDiff {
+	d := &models.ResultDiff{
+		Candidate: cand.summary,
+		Metrics:   []*models.MetricDiff{},
+		RetCodes:  []*models.RetCodeDiff{},
+	}
+	var pValue float64
+	hasPValue := len(base.histogram.Data) > 0 && len(cand.histogram.Data) > 0
+	if hasPValue {
+		pValue = mannWhitneyPValue(base.histogram, cand.histogram)
+		d.LatencyPValue = &pValue
+	}
+	alpha := 1 - comparisonConfidence
+
+	metrics := []struct {
+		name string
+		// value returns the metric of a result
+		value func(s *models.ResultSummary) float64
+		// higherIsWorse tells in which direction the metric regresses
+		higherIsWorse bool
+		// significant tells whether the change is statistically significant when there are too few baseline runs
+		significant func() (bool, string)
+	}{
+		{"p50_ms", func(s *models.ResultSummary) float64 { return s.P50Ms }, true, nil},
+		{"p90_ms", func(s *models.ResultSummary) float64 { return s.P90Ms }, true, nil},
+		{"p99_ms", func(s *models.ResultSummary) float64 { return s.P99Ms }, true, nil},
+		{"avg_ms", func(s *models.ResultSummary) float64 { return s.AvgMs }, true, nil},
+		{"qps", func(s *models.ResultSummary) float64 { return s.QPS }, false, nil},
+		{"error_percent", func(s *models.ResultSummary) float64 { return s.ErrorPercent }, true, func() (bool, string) {
+			p := twoProportionPValue(base.summary, cand.summary)
+			return p < alpha, fmt.Sprintf("two proportion z-test p=%.3g", p)
+		}},
+	}
+	for i := range metrics[:4] {
+		metrics[i].significant = func() (bool, string) {
+			if !hasPValue {
+				return true, "no histogram buckets to test the significance"
+			}
+			return pValue < alpha, fmt.Sprintf("Mann-Whitney U p=%.3g", pValue)
+		}
+	}
+	for _, m := range metrics {
+		bv, cv := m.value(base.summary), m.value(cand.summary)
+		md := newMetricDiff(m.name, bv, cv)
+		worse := cv > bv
+		if !m.higherIsWorse {
+			worse = cv < bv
+		}
+		// a rise of the error rate from zero has no relative change, any significant one counts
+		if worse && (math.Abs(md.DeltaPercent) > tolerance || (m.name == "error_percent" && bv == 0)) {
+			var (
+				significant bool
+				why         string
+			)
+			if len(baseRuns) >= 3 {
+				significant, why = outsidePredictionInterval(baseRuns, m.value, cv, m.higherIsWorse)
+			} else if m.significant != nil {
+				significant, why = m.significant()
+			} else {
+				significant, why = true, "too few baseline runs to test the significance"
+			}
+			if significant {
+				md.Regression = true
+				d.Regression = true
+				d.Reasons = append(d.Reasons, fmt.Sprintf("%s went from %.4g to %.4g (%+.1f%%, %s)", m.name, bv, cv, md.DeltaPercent, why))
+			}
+		}
+		d.Metrics = append(d.Metrics, md)
+	}
+
+	codes := map[string]bool{}
+	for code := range base.summary.RetCodes {
+		codes[code] = true
+	}
+	for code := range cand.summary.RetCodes {
+		codes[code] = true
+	}
+	for code := range codes {
+		d.RetCodes = append(d.RetCodes, &models.RetCodeDiff{
+			Code:      code,
+			Baseline:  base.summary.RetCodes[code],
+			Candidate: cand.summary.RetCodes[code],
+		})
+	}
+	sort.Slice(d.RetCodes, func(i, j int) bool {
+		return d.RetCodes[i].Code < d.RetCodes[j].Code
+	})
+
+	for k, bv := range base.serverMetrics {
+		if cv, ok := cand.serverMetrics[k]; ok {
+			d.ServerMetrics = append(d.ServerMetrics, newMetricDiff(k, bv, cv))
+		}
+	}
+	sort.Slice(d.ServerMetrics, func(i, j int) bool {
+		return d.ServerMetrics[i].Metric < d.ServerMetrics[j].Metric
+	})
+	return d
+}
+
+func newMetricDiff(name string, bv, cv float64) *models.MetricDiff {
+	md := &models.MetricDiff{
+		Metric:    name,
+		Baseline:  bv,
+		Candidate: cv,
+		Delta:     cv - bv,
+	}
+	if bv != 0 {
+		md.DeltaPercent = (cv - bv) * 100 / math.Abs(bv)
+	}
+	return md
+}
+
+// outsidePredictionInterval tells whether the candidate value is worse than the one sided 95% prediction
+// interval of a new run drawn like the baseline runs
+func outsidePredictionInterval(runs []*comparedResult, value func(s *models.ResultSummary) float64, cv float64, higherIsWorse bool) (bool, string) {
+	vs := make([]float64, len(runs))
+	for i, r := range runs {
+		vs[i] = value(r.summary)
+	}
+	mean, sd := meanStdDev(vs)
+	n := float64(len(vs))
+	margin := tQuantile95(len(vs)-1) * sd * math.Sqrt(1+1/n)
+	if higherIsWorse {
+		return cv > mean+margin, fmt.Sprintf("above %.4g, the 95%% prediction bound of %d baseline runs", mean+margin, len(vs))
+	}
+	return cv < mean-margin, fmt.Sprintf("below %.4g, the 95%% prediction bound of %d baseline runs", mean-margin, len(vs))
+}
+
+func tQuantile95(df int) float64 {
+	if df < 1 {
+		df = 1
+	}
+	if df <= len(tTable95) {
+		return tTable95[df-1]
+	}
+	return 1.645
+}
+
+// meanStdDev returns the mean and the sample standard deviation
+func meanStdDev(vs []float64) (float64, float64) {
+	if len(vs) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range vs {
+		sum += v
+	}
+	mean := sum / float64(len(vs))
+	if len(vs) < 2 {
+		return mean, 0
+	}
+	var sq float64
+	for _, v := range vs {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(vs)-1))
+}
+
+// mannWhitneyPValue returns the p-value of the one sided Mann-Whitney U test of the latencies of b being
+// higher than the ones of a, using the normal approximation with the tie correction.
+// The requests of a bucket are all considered to have the latency of its mid point.
+func mannWhitneyPValue(a, b *stats.HistogramData) float64 {
+	type bucket struct {
+		value  float64
+		na, nb float64
+	}
+	byValue := map[float64]*bucket{}
+	add := func(h *stats.HistogramData, isB bool) {
+		for _, iv := range h.Data {
+			v := (iv.Start + iv.End) / 2
+			bk, ok := byValue[v]
+			if !ok {
+				bk = &bucket{value: v}
+				byValue[v] = bk
+			}
+			if isB {
+				bk.nb += float64(iv.Count)
+			} else {
+				bk.na += float64(iv.Count)
+			}
+		}
+	}
+	add(a, false)
+	add(b, true)
+	buckets := make([]*bucket, 0, len(byValue))
+	for _, bk := range byValue {
+		buckets = append(buckets, bk)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].value < buckets[j].value
+	})
+
+	var na, nb, rankB, ties, rank float64
+	for _, bk := range buckets {
+		t := bk.na + bk.nb
+		// all the tied requests get the mid rank of their group
+		midRank := rank + (t+1)/2
+		rankB += midRank * bk.nb
+		ties += t*t*t - t
+		rank += t
+		na += bk.na
+		nb += bk.nb
+	}
+	n := na + nb
+	if na == 0 || nb == 0 || n < 2 {
+		return 1
+	}
+	u := rankB - nb*(nb+1)/2
+	mean := na * nb / 2
+	variance := na * nb / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (u - mean) / math.Sqrt(variance)
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+// twoProportionPValue returns the p-value of the one sided two proportion z-test of the error rate
+// of b being higher than the one of a
+func twoProportionPValue(a, b *models.ResultSummary) float64 {
+	na, nb := totalCount(a.RetCodes), totalCount(b.RetCodes)
+	if na == 0 || nb == 0 {
+		return 1
+	}
+	pa, pb := a.ErrorPercent/100, b.ErrorPercent/100
+	p := (pa*na + pb*nb) / (na + nb)
+	se := math.Sqrt(p * (1 - p) * (1/na + 1/nb))
+	if se == 0 {
+		return 1
+	}
+	return 0.5 * math.Erfc((pb-pa)/se/math.Sqrt2)
+}
+
+// serverMetricMeans returns the mean of each Prometheus series recorded with a result,
+// keyed by the query and the labels of the series
+func serverMetricMeans(serverMetrics interface{}) map[string]float64 {
+	means := map[string]float64{}
+	if serverMetrics == nil {
+		return means
+	}
+	bd, err := json.Marshal(serverMetrics)
+	if err != nil {
+		return means
+	}
+	queries := map[string]struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Values [][]interface{}   `json:"values"`
+				Value  []interface{}     `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}{}
+	if err = json.Unmarshal(bd, &queries); err != nil {
+		return means
+	}
+	for query, qr := range queries {
+		for _, series := range qr.Data.Result {
+			samples := series.Values
+			if len(series.Value) > 0 {
+				samples = append(samples, series.Value)
+			}
+			var vs []float64
+			for _, sample := range samples {
+				if len(sample) != 2 {
+					continue
+				}
+				s, _ := sample[1].(string)
+				v, err := strconv.ParseFloat(s, 64)
+				if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+					continue
+				}
+				vs = append(vs, v)
+			}
+			if len(vs) == 0 {
+				continue
+			}
+			labels := make([]string, 0, len(series.Metric))
+			for k, v := range series.Metric {
+				labels = append(labels, fmt.Sprintf("%s=%q", k, v))
+			}
+			sort.Strings(labels)
+			means[query+"{"+strings.Join(labels, ",")+"}"], _ = meanStdDev(vs)
+		}
+	}
+	return means
+}
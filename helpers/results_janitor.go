@@ -0,0 +1,177 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultResultsJanitorInterval - how often the janitor goes over the results store by default
+const DefaultResultsJanitorInterval = time.Hour
+
+// ResultsJanitor deletes the local results the retention policy does not keep and compacts the store
+// to give their space back
+type ResultsJanitor struct {
+	persister *models.BitCaskResultsPersister
+	policy    *models.ResultsRetentionPolicy
+	interval  time.Duration
+
+	// runLock keeps the runs on the interval and those requested from the API from overlapping
+	runLock           *sync.Mutex
+	sLock             *sync.Mutex
+	lastRun           *models.ResultsJanitorRun
+	deletedSinceStart int
+}
+
+// NewResultsJanitor creates a new instance of ResultsJanitor enforcing the policy every interval
+func NewResultsJanitor(persister *models.BitCaskResultsPersister, policy *models.ResultsRetentionPolicy, interval time.Duration) (*ResultsJanitor, error) {
+	if policy == nil {
+		policy = &models.ResultsRetentionPolicy{}
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = DefaultResultsJanitorInterval
+	}
+	return &ResultsJanitor{
+		persister: persister,
+		policy:    policy,
+		interval:  interval,
+		runLock:   &sync.Mutex{},
+		sLock:     &sync.Mutex{},
+	}, nil
+}
+
+// Run goes over the store every interval until the context is done
+func (j *ResultsJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := j.RunOnce(ctx); err != nil {
+			logrus.Warnf("unable to enforce the results retention: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce deletes the results which are expired or beyond the size of the store allowed by the policy,
+// then compacts the store when results were deleted or overwritten since the last compaction
+func (j *ResultsJanitor) RunOnce(ctx context.Context) (*models.ResultsJanitorRun, error) {
+	if j.persister == nil {
+		return nil, errors.New("results are not stored locally")
+	}
+	j.runLock.Lock()
+	defer j.runLock.Unlock()
+
+	run := &models.ResultsJanitorRun{StartedAt: time.Now()}
+	err := j.run(ctx, run)
+	run.FinishedAt = time.Now()
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	j.sLock.Lock()
+	j.lastRun = run
+	j.deletedSinceStart += run.Deleted
+	j.sLock.Unlock()
+
+	if run.Deleted > 0 || run.Compacted {
+		logrus.Infof("deleted %d results, store size went from %d to %d bytes", run.Deleted, run.SizeBeforeBytes, run.SizeAfterBytes)
+	}
+	return run, err
+}
+
+func (j *ResultsJanitor) run(ctx context.Context, run *models.ResultsJanitorRun) error {
+	status, err := j.persister.Stats()
+	if err != nil {
+		return err
+	}
+	run.SizeBeforeBytes = status.SizeBytes
+	run.SizeAfterBytes = status.SizeBytes
+
+	if err := j.delete(ctx, run, j.persister.ExpiredResults(j.policy, run.StartedAt)); err != nil {
+		return err
+	}
+
+	if j.policy.MaxSizeBytes > 0 && status.SizeBytes > j.policy.MaxSizeBytes {
+		// the space of the results already deleted is given back first, so that no more results are deleted than needed
+		if status, err = j.compact(run); err != nil {
+			return err
+		}
+		if excess := status.SizeBytes - j.policy.MaxSizeBytes; excess > 0 {
+			if err := j.delete(ctx, run, j.persister.OldestResults(j.policy, excess)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if status, err = j.persister.Stats(); err != nil {
+		return err
+	}
+	run.SizeAfterBytes = status.SizeBytes
+	if status.StaleWrites > 0 {
+		if _, err := j.compact(run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *ResultsJanitor) delete(ctx context.Context, run *models.ResultsJanitorRun, ids []uuid.UUID) error {
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := j.persister.DeleteResult(id)
+		switch {
+		case err == models.ErrResultNotFound:
+			// deleted in the meantime
+		case err != nil:
+			return errors.Wrapf(err, "unable to delete result %s", id)
+		default:
+			run.Deleted++
+		}
+	}
+	return nil
+}
+
+func (j *ResultsJanitor) compact(run *models.ResultsJanitorRun) (*models.ResultsStoreStatus, error) {
+	if err := j.persister.Compact(); err != nil {
+		return nil, err
+	}
+	run.Compacted = true
+	status, err := j.persister.Stats()
+	if err != nil {
+		return nil, err
+	}
+	run.SizeAfterBytes = status.SizeBytes
+	return status, nil
+}
+
+// Status returns the state of the store together with the policy and the last run of the janitor
+func (j *ResultsJanitor) Status(ctx context.Context) (*models.ResultsStoreStatus, error) {
+	if j.persister == nil {
+		return nil, errors.New("results are not stored locally")
+	}
+	status, err := j.persister.Stats()
+	if err != nil {
+		return nil, err
+	}
+	j.sLock.Lock()
+	defer j.sLock.Unlock()
+	status.Policy = j.policy
+	status.Interval = j.interval
+	status.LastRun = j.lastRun
+	status.DeletedSinceStart = j.deletedSinceStart
+	return status, nil
+}
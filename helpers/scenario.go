@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadTestFunc runs a load test for the given options
+type LoadTestFunc func(context.Context, *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error)
+
+type endpointRun struct {
+	endpoint   *models.LoadTestEndpoint
+	resultsMap map[string]interface{}
+	result     *fhttp.HTTPRunnerResults
+}
+
+// ScenarioLoadTest runs all the endpoints of a scenario concurrently using the given load test func,
+// the QPS and the threads are split between the endpoints according to their weight.
+// The returned map holds the aggregate of all the runs along with each endpoint's own result under "endpoints".
+func ScenarioLoadTest(ctx context.Context, opts *models.LoadTestOptions, runFunc LoadTestFunc) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if len(opts.Endpoints) == 0 {
+		err := errors.New("no endpoints given for the scenario")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	totalWeight := 0
+	for _, ep := range opts.Endpoints {
+		totalWeight += ep.Weight
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	runs := make([]*endpointRun, len(opts.Endpoints))
+	errs := make([]error, len(opts.Endpoints))
+	wg := &sync.WaitGroup{}
+	for i, ep := range opts.Endpoints {
+		share := float64(ep.Weight) / float64(totalWeight)
+		epOpts := *opts
+		epOpts.Endpoints = nil
+		epOpts.URL = ep.URL
+		if ep.Method != "" {
+			epOpts.Method = ep.Method
+		}
+		if len(ep.Headers) > 0 {
+			// the endpoint headers are added to, or override, the ones of the test
+			epOpts.Headers = map[string]string{}
+			for k, v := range opts.Headers {
+				epOpts.Headers[k] = v
+			}
+			for k, v := range ep.Headers {
+				epOpts.Headers[k] = v
+			}
+		}
+		epOpts.Name = opts.Name + " -_- " + ep.Name
+		if opts.HTTPQPS > 0 {
+			epOpts.HTTPQPS = opts.HTTPQPS * share
+		}
+		epOpts.HTTPNumThreads = int(math.Max(1, math.Round(float64(opts.HTTPNumThreads)*share)))
+
+		wg.Add(1)
+		go func(i int, ep *models.LoadTestEndpoint, epOpts *models.LoadTestOptions) {
+			defer wg.Done()
+			resultsMap, _, err := runFunc(ctx, epOpts)
+			if err == nil {
+				runs[i], err = newEndpointRun(ep, resultsMap)
+			}
+			if err != nil {
+				errs[i] = errors.Wrapf(err, "error while testing endpoint %s", ep.Name)
+				// no point in carrying on with the other endpoints
+				cancel()
+			}
+		}(i, ep, &epOpts)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			logrus.Error(err)
+			return nil, nil, err
+		}
+	}
+
+	results := make([]*fhttp.HTTPRunnerResults, len(runs))
+	for i, run := range runs {
+		results[i] = run.result
+	}
+	agg := aggregateHTTPResults(opts.Name+" -_- scenario", opts.URL, results, false)
+	resultsMap, err := toResultsMap(agg)
+	if err != nil {
+		return nil, nil, err
+	}
+	endpoints := make([]interface{}, len(runs))
+	for i, run := range runs {
+		endpoints[i] = map[string]interface{}{
+			"name":    run.endpoint.Name,
+			"url":     run.endpoint.URL,
+			"weight":  run.endpoint.Weight,
+			"method":  run.endpoint.Method,
+			"headers": run.endpoint.Headers,
+			"result":  run.resultsMap,
+		}
+	}
+	resultsMap["endpoints"] = endpoints
+	return resultsMap, agg.Result(), nil
+}
+
+func newEndpointRun(ep *models.LoadTestEndpoint, resultsMap map[string]interface{}) (*endpointRun, error) {
+	result, err := toHTTPRunnerResults(resultsMap)
+	if err != nil {
+		return nil, err
+	}
+	return &endpointRun{
+		endpoint:   ep,
+		resultsMap: resultsMap,
+		result:     result,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// rampStepDuration is the shortest time a ramp runs at the same QPS
+	rampStepDuration = 5 * time.Second
+	// maxRampSteps caps the number of QPS changes in a single ramp
+	maxRampSteps = 20
+)
+
+// StagedLoadTest runs the stages of the load profile one after the other using the given load test func.
+// The returned map holds the aggregate of all the stages along with each stage's own result under "stages".
+func StagedLoadTest(ctx context.Context, opts *models.LoadTestOptions, runFunc LoadTestFunc) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if len(opts.Stages) == 0 {
+		err := errors.New("no stages given for the load profile")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	var (
+		prevQPS float64
+		stopped bool
+	)
+	stageResults := make([]*fhttp.HTTPRunnerResults, 0, len(opts.Stages))
+	stages := make([]interface{}, 0, len(opts.Stages))
+	for _, stage := range opts.Stages {
+		if stopped {
+			break
+		}
+		stageOpts := *opts
+		stageOpts.Stages = nil
+		if stage.Threads > 0 {
+			stageOpts.HTTPNumThreads = stage.Threads
+		}
+
+		var steps []*fhttp.HTTPRunnerResults
+		for _, step := range stageSteps(stage, prevQPS) {
+			stageOpts.Duration = step.Duration
+			stageOpts.HTTPQPS = step.QPS
+			resultsMap, _, err := runFunc(ctx, &stageOpts)
+			if errors.Cause(err) == errLoadTestStopped {
+				// the stop conditions were met, the stages run so far make the result
+				stopped = true
+				break
+			}
+			if err != nil {
+				err = errors.Wrapf(err, "error while running stage %s", stage.Name)
+				logrus.Error(err)
+				return nil, nil, err
+			}
+			result, err := toHTTPRunnerResults(resultsMap)
+			if err != nil {
+				err = errors.Wrapf(err, "error while converting the results of stage %s", stage.Name)
+				logrus.Error(err)
+				return nil, nil, err
+			}
+			steps = append(steps, result)
+		}
+		prevQPS = stage.QPS
+		if len(steps) == 0 {
+			break
+		}
+
+		stageResult := steps[0]
+		if len(steps) > 1 {
+			stageResult = aggregateHTTPResults(opts.Name+" -_- "+stage.Name, opts.URL, steps, true)
+		}
+		stageMap, err := toResultsMap(stageResult)
+		if err != nil {
+			return nil, nil, err
+		}
+		stageResults = append(stageResults, stageResult)
+		stages = append(stages, map[string]interface{}{
+			"name":     stage.Name,
+			"type":     string(stage.Type),
+			"duration": stage.Duration.String(),
+			"qps":      stage.QPS,
+			"threads":  stageOpts.HTTPNumThreads,
+			"result":   stageMap,
+		})
+	}
+
+	if len(stageResults) == 0 {
+		return nil, nil, errLoadTestStopped
+	}
+	agg := aggregateHTTPResults(opts.Name+" -_- staged", opts.URL, stageResults, true)
+	resultsMap, err := toResultsMap(agg)
+	if err != nil {
+		return nil, nil, err
+	}
+	resultsMap["stages"] = stages
+	return resultsMap, agg.Result(), nil
+}
+
+// stageSteps splits a stage into the constant QPS runs needed to execute it,
+// a ramp moves from the QPS of the previous stage to its own in equal steps
+func stageSteps(stage *models.LoadTestStage, prevQPS float64) []*models.LoadTestStage {
+	if stage.Type != models.RampStage {
+		return []*models.LoadTestStage{stage}
+	}
+	n := int(stage.Duration / rampStepDuration)
+	if n < 1 {
+		n = 1
+	}
+	if n > maxRampSteps {
+		n = maxRampSteps
+	}
+	stepDuration := stage.Duration / time.Duration(n)
+	steps := make([]*models.LoadTestStage, n)
+	for i := 0; i < n; i++ {
+		steps[i] = &models.LoadTestStage{
+			Duration: stepDuration,
+			QPS:      prevQPS + (stage.QPS-prevQPS)*float64(i+1)/float64(n),
+		}
+	}
+	return steps
+}
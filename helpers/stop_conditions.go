@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// minStopWindowRequests is the number of requests needed in the window before the error rate and the latency are checked
+const minStopWindowRequests = 10
+
+// errLoadTestStopped is returned for the runs started after the stop conditions of the load test were met
+var errLoadTestStopped = errors.New("the stop conditions of the load test were met")
+
+type stopContextKey struct{}
+
+// loadTestStopper keeps track of the stop conditions of a load test, it is shared by all the runs of the test
+type loadTestStopper struct {
+	conds  *models.LoadTestStopConditions
+	reason string
+	done   chan struct{}
+	sLock  *sync.Mutex
+}
+
+func newLoadTestStopper(conds *models.LoadTestStopConditions) *loadTestStopper {
+	return &loadTestStopper{
+		conds: conds,
+		done:  make(chan struct{}),
+		sLock: &sync.Mutex{},
+	}
+}
+
+// stopped returns the reason the test was stopped for, false while it should carry on
+func (s *loadTestStopper) stopped() (string, bool) {
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	return s.reason, s.reason != ""
+}
+
+// stop records why the test was stopped and cancels its runs, the first reason is kept
+func (s *loadTestStopper) stop(reason string) {
+	s.sLock.Lock()
+	defer s.sLock.Unlock()
+	if s.reason == "" {
+		s.reason = reason
+		close(s.done)
+		logrus.Infof("stopping the load test: %s", reason)
+	}
+}
+
+// check returns the condition met by the requests of the window of the recorder, empty when there is none
+func (s *loadTestStopper) check(recorder *LoadTestRecorder) string {
+	h, total, failed := recorder.windowMetrics()
+	if total < minStopWindowRequests {
+		return ""
+	}
+	if s.conds.MaxErrorPercent > 0 {
+		if errorPercent := float64(failed) * 100 / float64(total); errorPercent > s.conds.MaxErrorPercent {
+			return fmt.Sprintf("%.1f%% of the requests failed over the last %v, above the limit of %g%%",
+				errorPercent, s.conds.Window, s.conds.MaxErrorPercent)
+		}
+	}
+	if s.conds.MaxLatencyMs > 0 {
+		if latency := models.PercentileMs(h, s.conds.LatencyPercentile); latency > s.conds.MaxLatencyMs {
+			return fmt.Sprintf("the p%g latency was %.1fms over the last %v, above the limit of %gms",
+				s.conds.LatencyPercentile, latency, s.conds.Window, s.conds.MaxLatencyMs)
+		}
+	}
+	return ""
+}
+
+// stopConditionsLoadTest runs the load test once using the given load test func, its requests are recorded
+// over the window of the stop conditions, which are checked every check interval while the status codes
+// are checked on every request. The run is cancelled once a condition is met and its results are kept.
+func stopConditionsLoadTest(ctx context.Context, opts *models.LoadTestOptions, runFunc LoadTestFunc, stopper *loadTestStopper) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if _, ok := stopper.stopped(); ok {
+		return nil, nil, errLoadTestStopped
+	}
+	recorder := newWindowRecorder(opts.Duration, stopper.conds.Window, loadTestRecorderFrom(ctx))
+	recorder.observe = func(code int) {
+		if stopper.conds.StopsOn(code) {
+			stopper.stop(fmt.Sprintf("received status code %d", code))
+		}
+	}
+	runCtx, cancel := context.WithCancel(WithLoadTestRecorder(ctx, recorder))
+	defer cancel()
+	runCtx = context.WithValue(runCtx, stopContextKey{}, ctx)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(stopper.conds.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-stopper.done:
+				cancel()
+				return
+			case <-ticker.C:
+				if reason := stopper.check(recorder); reason != "" {
+					stopper.stop(reason)
+				}
+			}
+		}
+	}()
+	return runFunc(runCtx, opts)
+}
+
+// loadTestCanceled returns the error of the context of a run, nil when the run was only cancelled
+// by the stop conditions of the test as its results are kept then
+func loadTestCanceled(ctx context.Context) error {
+	if parent, ok := ctx.Value(stopContextKey{}).(context.Context); ok {
+		return parent.Err()
+	}
+	return ctx.Err()
+}
+
+// markAborted records on the results why the load test was stopped before its end
+func markAborted(resultsMap map[string]interface{}, reason string, at time.Time) {
+	resultsMap["Aborted"] = map[string]interface{}{
+		"reason": reason,
+		"at":     at,
+	}
+}
@@ -15,11 +15,15 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,17 +31,70 @@ import (
 )
 
 var (
-	testURL  = ""
-	testName = ""
-	testMesh = ""
-	// testFile         = ""
+	testURL            = ""
+	testName           = ""
+	testMesh           = ""
+	testFile           = ""
 	qps                = ""
 	concurrentRequests = ""
 	testDuration       = ""
 	loadGenerator      = ""
 	testCookie         = ""
+	grpcTest           = false
+	grpcStreams        = 0
+	grpcPing           = false
+	grpcPingDelay      = ""
+	grpcHealthSvc      = ""
+	storedCerts        = false
+	arrival            = ""
+	inCluster          = false
+	testNamespace      = ""
+	injectSidecar      = false
+	maxP50             = ""
+	maxP90             = ""
+	maxP99             = ""
+	maxErrorPercent    = 0.0
+	minQPS             = 0.0
+	abortOn            = ""
+	abortErrorPercent  = 0.0
+	abortLatency       = ""
+	abortWindow        = ""
+	testProfile        = ""
 )
 
+// perfResponse is the part of the load test events needed to tell whether the test passed
+type perfResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Verdict *struct {
+		Passed bool `json:"passed"`
+	} `json:"verdict"`
+	Snapshot *perfSnapshot `json:"snapshot"`
+}
+
+// perfSnapshot is the part of the live metrics of a running test shown while it runs
+type perfSnapshot struct {
+	ElapsedSeconds  float64 `json:"elapsed_seconds"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Partial         bool    `json:"partial"`
+	Count           int64   `json:"count"`
+	QPS             float64 `json:"qps"`
+	P50Ms           float64 `json:"p50_ms"`
+	P90Ms           float64 `json:"p90_ms"`
+	P99Ms           float64 `json:"p99_ms"`
+	ErrorPercent    float64 `json:"error_percent"`
+}
+
+// String formats the snapshot as a single progress line
+func (s *perfSnapshot) String() string {
+	line := fmt.Sprintf("[%4.0fs/%.0fs]", s.ElapsedSeconds, s.DurationSeconds)
+	if s.Partial {
+		return line + " running"
+	}
+	return fmt.Sprintf("%s %d requests, %.1f qps, p50 %.1fms p90 %.1fms p99 %.1fms, %.1f%% errors",
+		line, s.Count, s.QPS, s.P50Ms, s.P90Ms, s.P99Ms, s.ErrorPercent)
+}
+
 var seededRand = rand.New(
 	rand.NewSource(time.Now().UnixNano()))
 
@@ -60,8 +117,28 @@ var perfCmd = &cobra.Command{
 		//Check prerequisite
 		preReqCheck()
 
+		if len(testProfile) > 0 {
+			req, err := profileTestRequest(cmd)
+			if err != nil {
+				println("Error: " + err.Error())
+				os.Exit(1)
+			}
+			runPerfTest(req)
+			return
+		}
+
 		println("Test name used : ", testName)
 
+		if len(testFile) > 0 {
+			req, err := fileTestRequest(testFile)
+			if err != nil {
+				println("Error: " + err.Error())
+				os.Exit(1)
+			}
+			runPerfTest(req)
+			return
+		}
+
 		const mesheryURL string = "http://localhost:9081/api/load-test-smps?"
 		postData := ""
 
@@ -69,7 +146,7 @@ var perfCmd = &cobra.Command{
 		duration, err := time.ParseDuration(testDuration)
 		if err != nil {
 			println("Error: Test duration invalid")
-			return
+			os.Exit(1)
 		}
 		endTime := startTime.Add(duration)
 
@@ -80,57 +157,388 @@ var perfCmd = &cobra.Command{
 			postData = postData + "\nendpoint_url: " + testURL
 		} else {
 			println("Error: Please enter a TestURL")
-			return
+			os.Exit(1)
 		}
 
 		postData = postData + "\nclient:"
 		postData = postData + "\n connections: " + concurrentRequests
 		postData = postData + "\n rps: " + qps
+		if len(arrival) > 0 {
+			postData = postData + "\n arrival: " + arrival
+		}
+		if inCluster {
+			postData = postData + "\n internal: true"
+		}
+		if grpcTest {
+			postData = postData + "\n protocol: grpc"
+			postData = postData + "\n grpc:"
+			postData = postData + "\n  streams: " + strconv.Itoa(grpcStreams)
+			postData = postData + "\n  ping: " + strconv.FormatBool(grpcPing)
+			if len(grpcPingDelay) > 0 {
+				if _, err := time.ParseDuration(grpcPingDelay); err != nil {
+					println("Error: gRPC ping delay invalid")
+					os.Exit(1)
+				}
+				postData = postData + "\n  ping_delay: " + grpcPingDelay
+			}
+			if len(grpcHealthSvc) > 0 {
+				postData = postData + "\n  health_service: " + grpcHealthSvc
+			}
+		}
+
+		thresholds := ""
+		for _, t := range []struct {
+			name, value string
+		}{{"p50_ms", maxP50}, {"p90_ms", maxP90}, {"p99_ms", maxP99}} {
+			if len(t.value) == 0 {
+				continue
+			}
+			d, err := time.ParseDuration(t.value)
+			if err != nil {
+				println("Error: latency threshold invalid: " + t.value)
+				os.Exit(1)
+			}
+			thresholds = thresholds + "\n " + t.name + ": " + strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+		}
+		if maxErrorPercent > 0 {
+			thresholds = thresholds + "\n max_error_percent: " + strconv.FormatFloat(maxErrorPercent, 'f', -1, 64)
+		}
+		if minQPS > 0 {
+			thresholds = thresholds + "\n min_qps: " + strconv.FormatFloat(minQPS, 'f', -1, 64)
+		}
+		if len(thresholds) > 0 {
+			postData = postData + "\nthresholds:" + thresholds
+		}
+
+		stopConditions := ""
+		if len(abortOn) > 0 {
+			codes := strings.Split(abortOn, ",")
+			for _, code := range codes {
+				if _, err := strconv.Atoi(strings.TrimSpace(code)); err != nil {
+					println("Error: status code to abort on invalid: " + code)
+					os.Exit(1)
+				}
+			}
+			stopConditions = stopConditions + "\n status_codes: [" + strings.Join(codes, ", ") + "]"
+		}
+		if abortErrorPercent > 0 {
+			stopConditions = stopConditions + "\n max_error_percent: " + strconv.FormatFloat(abortErrorPercent, 'f', -1, 64)
+		}
+		if len(abortLatency) > 0 {
+			d, err := time.ParseDuration(abortLatency)
+			if err != nil {
+				println("Error: latency to abort on invalid: " + abortLatency)
+				os.Exit(1)
+			}
+			stopConditions = stopConditions + "\n max_latency_ms: " + strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+		}
+		if len(stopConditions) > 0 && len(abortWindow) > 0 {
+			if _, err := time.ParseDuration(abortWindow); err != nil {
+				println("Error: abort window invalid: " + abortWindow)
+				os.Exit(1)
+			}
+			stopConditions = stopConditions + "\n window: " + abortWindow
+		}
+		if len(stopConditions) > 0 {
+			postData = postData + "\nstop_conditions:" + stopConditions
+		}
 
 		req, err := http.NewRequest("POST", mesheryURL, bytes.NewBuffer([]byte(postData)))
 		if err != nil {
 			println("Error in building the request")
-			return
+			os.Exit(1)
 		}
-		cookieConf := strings.SplitN(testCookie, "=", 2)
-		cookieName := cookieConf[0]
-		cookieValue := cookieConf[1]
-		req.AddCookie(&http.Cookie{Name: cookieName, Value: cookieValue})
-		q := req.URL.Query()
-		q.Add("name", testName)
-		q.Add("loadGenerator", loadGenerator)
-		if len(testMesh) > 0 {
-			q.Add("mesh", testMesh)
+		addSMPSTestQuery(req)
+
+		runPerfTest(req)
+	},
+}
+
+// addSMPSTestQuery adds the parameters of the test which are not part of the SMPS document
+func addSMPSTestQuery(req *http.Request) {
+	q := req.URL.Query()
+	q.Add("name", testName)
+	q.Add("loadGenerator", loadGenerator)
+	if len(testMesh) > 0 {
+		q.Add("mesh", testMesh)
+	}
+	if storedCerts {
+		q.Add("storedCerts", "true")
+	}
+	if inCluster {
+		if len(testNamespace) > 0 {
+			q.Add("namespace", testNamespace)
 		}
-		req.URL.RawQuery = q.Encode()
+		q.Add("sidecar", strconv.FormatBool(injectSidecar))
+	}
+	req.URL.RawQuery = q.Encode()
+}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return
+// addProviderCookie adds the cookie identifying the provider to the request
+func addProviderCookie(req *http.Request) {
+	cookieConf := strings.SplitN(testCookie, "=", 2)
+	cookieName := cookieConf[0]
+	cookieValue := cookieConf[1]
+	req.AddCookie(&http.Cookie{Name: cookieName, Value: cookieValue})
+}
+
+// smpsValidation is the outcome of the validation of an SMPS document by Meshery
+type smpsValidation struct {
+	Valid  bool `json:"valid"`
+	Errors []struct {
+		Line    int    `json:"line"`
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// fileTestRequest builds the request running the test described by the SMPS file, the file is checked locally
+// and then validated by Meshery, which knows the load generators, when it can be reached for it
+func fileTestRequest(file string) (*http.Request, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the test file: %v", err)
+	}
+	if err := checkSMPSFile(file, data); err != nil {
+		return nil, err
+	}
+	if err := validateSMPSFile(file, data); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", "http://localhost:9081/api/load-test-smps", bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	addSMPSTestQuery(req)
+	return req, nil
+}
+
+// validateSMPSFile asks Meshery to validate the SMPS document and prints each of its problems,
+// the validation is skipped with a warning when Meshery does not answer it
+func validateSMPSFile(file string, data []byte) error {
+	req, err := http.NewRequest("POST", "http://localhost:9081/api/load-test-smps/validate", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	addSMPSTestQuery(req)
+	addProviderCookie(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		println("Warning: unable to validate the test file with Meshery: " + err.Error())
+		return nil
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		println("Warning: unable to validate the test file with Meshery: " + err.Error())
+		return nil
+	}
+	validation := &smpsValidation{}
+	if err := json.Unmarshal(body, validation); err != nil {
+		println("Warning: unable to validate the test file with Meshery: " + strings.TrimSpace(string(body)))
+		return nil
+	}
+	if validation.Valid {
+		return nil
+	}
+	for _, e := range validation.Errors {
+		msg := e.Message
+		if len(e.Field) > 0 {
+			msg = e.Field + ": " + msg
 		}
+		if e.Line > 0 {
+			msg = fmt.Sprintf("line %d: %s", e.Line, msg)
+		}
+		println(file + ": " + msg)
+	}
+	return fmt.Errorf("%s is not a valid SMPS document", file)
+}
+
+// runPerfTest sends the test request with the provider cookie and follows the test events until the end,
+// it exits with an error when the test failed or did not meet its thresholds
+func runPerfTest(req *http.Request) {
+	addProviderCookie(req)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		println("Error: unable to reach Meshery: " + err.Error())
+		os.Exit(1)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 
-		buf := make([]byte, 4)
-		for {
-			n, err := resp.Body.Read(buf)
-			fmt.Print(string(buf[:n]))
-			if err == io.EOF {
-				break
+	if resp.StatusCode != http.StatusOK {
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(resp.Body)
+		println("Error: " + strings.TrimSpace(buf.String()))
+		os.Exit(1)
+	}
+
+	var last perfResponse
+	scanner := bufio.NewScanner(resp.Body)
+	// the last event carries the whole result
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		data := strings.TrimPrefix(line, "data: ")
+		if data == line {
+			fmt.Println(line)
+			continue
+		}
+		var event perfResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			fmt.Println(line)
+			continue
+		}
+		// the live metrics are shown as a progress line and do not change the outcome of the test
+		if event.Status == "progress" {
+			if event.Snapshot != nil {
+				fmt.Println(event.Snapshot.String())
 			}
+			continue
 		}
-		println("\nTest Completed Successfully!")
-	},
+		fmt.Println(line)
+		last = event
+	}
+	if err := scanner.Err(); err != nil {
+		println("Error: unable to read the test events: " + err.Error())
+		os.Exit(1)
+	}
+	if last.Status != "success" {
+		println("\nTest Failed: " + last.Message)
+		os.Exit(1)
+	}
+	if last.Verdict != nil && !last.Verdict.Passed {
+		println("\nTest Completed but did not meet its thresholds!")
+		os.Exit(1)
+	}
+	println("\nTest Completed Successfully!")
+}
+
+// profileTestRequest builds the request running the saved profile, the flags given along with it override the profile
+func profileTestRequest(cmd *cobra.Command) (*http.Request, error) {
+	flags := cmd.Flags()
+	if flags.Changed("file") {
+		return nil, fmt.Errorf("a test file cannot be used with a profile")
+	}
+	if !inCluster && (flags.Changed("namespace") || flags.Changed("sidecar")) {
+		return nil, fmt.Errorf("--namespace and --sidecar can only be used with --in-cluster")
+	}
+	for _, flag := range []string{"grpc-streams", "grpc-ping", "grpc-ping-delay", "grpc-health-svc"} {
+		if !grpcTest && flags.Changed(flag) {
+			return nil, fmt.Errorf("--%s can only be used with --grpc", flag)
+		}
+	}
+
+	req, err := http.NewRequest("GET", "http://localhost:9081/api/load-test", nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("profile", testProfile)
+	for flag, param := range map[string]string{
+		"name":                "name",
+		"url":                 "url",
+		"mesh":                "mesh",
+		"qps":                 "qps",
+		"concurrent-requests": "c",
+		"load-generator":      "loadGenerator",
+		"arrival":             "arrival",
+		"namespace":           "namespace",
+		"grpc-ping-delay":     "grpcPingDelay",
+		"grpc-health-svc":     "grpcHealthSvc",
+		"abort-on":            "abortOn",
+		"abort-window":        "abortWindow",
+	} {
+		if flags.Changed(flag) {
+			v, _ := flags.GetString(flag)
+			q.Add(param, v)
+		}
+	}
+	for flag, param := range map[string]string{
+		"grpc":       "grpc",
+		"grpc-ping":  "grpcPing",
+		"in-cluster": "inCluster",
+		"sidecar":    "sidecar",
+	} {
+		if flags.Changed(flag) {
+			v, _ := flags.GetBool(flag)
+			q.Add(param, strconv.FormatBool(v))
+		}
+	}
+	if flags.Changed("grpc-streams") {
+		q.Add("grpcStreams", strconv.Itoa(grpcStreams))
+	}
+	if flags.Changed("duration") {
+		d, err := time.ParseDuration(testDuration)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("test duration invalid")
+		}
+		q.Add("t", strconv.FormatInt(int64(d/time.Millisecond), 10))
+		q.Add("dur", "ms")
+	}
+	for flag, param := range map[string]string{"p50": "p50Ms", "p90": "p90Ms", "p99": "p99Ms", "abort-on-latency": "abortOnLatencyMs"} {
+		if !flags.Changed(flag) {
+			continue
+		}
+		v, _ := flags.GetString(flag)
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("latency invalid: %s", v)
+		}
+		q.Add(param, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64))
+	}
+	if maxErrorPercent > 0 {
+		q.Add("maxErrorPercent", strconv.FormatFloat(maxErrorPercent, 'f', -1, 64))
+	}
+	if minQPS > 0 {
+		q.Add("minQps", strconv.FormatFloat(minQPS, 'f', -1, 64))
+	}
+	if abortErrorPercent > 0 {
+		q.Add("abortOnErrorPercent", strconv.FormatFloat(abortErrorPercent, 'f', -1, 64))
+	}
+	if storedCerts {
+		q.Add("storedCerts", "true")
+	}
+	req.URL.RawQuery = q.Encode()
+	return req, nil
 }
 
 func init() {
-	perfCmd.Flags().StringVar(&testURL, "url", "", "(required) URL of the endpoint to use for the test")
+	perfCmd.Flags().StringVar(&testURL, "url", "", "(required) URL of the endpoint to use for the test, unless a profile is used")
 	perfCmd.Flags().StringVar(&testName, "name", StringWithCharset(8), "(optional) A memorable name for the test.")
 	perfCmd.Flags().StringVar(&testMesh, "mesh", "", "(optional) Name of the service mesh.")
-	// perfCmd.Flags().StringVar(&testFile, "file", "", "DESCRIPTION")
+	perfCmd.Flags().StringVar(&testFile, "file", "", "(optional) SMPS file describing the test, it is validated before being submitted and the flags describing the test are then not used")
 	perfCmd.Flags().StringVar(&qps, "qps", "0", "(optional) Queries per second")
 	perfCmd.Flags().StringVar(&concurrentRequests, "concurrent-requests", "1", "DESCRIPTION")
 	perfCmd.Flags().StringVar(&testDuration, "duration", "30s", "(optional) Duration of the test like 10s, 5m, 2h. We are following the convention described at https://golang.org/pkg/time/#ParseDuration")
 	perfCmd.Flags().StringVar(&testCookie, "cookie", "meshery-provider=Default Local Provider", "(required) identification of choice of provider.")
-	perfCmd.Flags().StringVar(&loadGenerator, "load-generator", "fortio", "	(optional) choice of load generator: fortio (OR) wrk2")
+	perfCmd.Flags().StringVar(&loadGenerator, "load-generator", "fortio", "	(optional) choice of load generator: fortio (OR) wrk2 (OR) native")
+	perfCmd.Flags().BoolVar(&grpcTest, "grpc", false, "(optional) Run a gRPC load test, the url is then the host:port of the gRPC service")
+	perfCmd.Flags().IntVar(&grpcStreams, "grpc-streams", 1, "(optional) Number of gRPC streams per connection")
+	perfCmd.Flags().BoolVar(&grpcPing, "grpc-ping", false, "(optional) Use the fortio gRPC ping service instead of the gRPC health check")
+	perfCmd.Flags().StringVar(&grpcPingDelay, "grpc-ping-delay", "", "(optional) Delay requested from the gRPC ping service like 10ms")
+	perfCmd.Flags().StringVar(&grpcHealthSvc, "grpc-health-svc", "", "(optional) Service name used for the gRPC health check")
+	perfCmd.Flags().StringVar(&arrival, "arrival", "", "(optional) Arrival of the requests for the native load generator: constant (OR) poisson")
+	perfCmd.Flags().BoolVar(&storedCerts, "stored-certs", false, "(optional) Use the TLS client certificates saved in Meshery to test services requiring mutual TLS")
+	perfCmd.Flags().BoolVar(&inCluster, "in-cluster", false, "(optional) Run the load generator as a Job in the Kubernetes cluster configured in Meshery")
+	perfCmd.Flags().StringVar(&testNamespace, "namespace", "", "(optional) Namespace of the in-cluster load test Job, default namespace when not set")
+	perfCmd.Flags().BoolVar(&injectSidecar, "sidecar", false, "(optional) Inject the service mesh sidecar into the in-cluster load test Job")
+	perfCmd.Flags().StringVar(&maxP50, "p50", "", "(optional) Maximum median latency like 100ms, the command fails when it is exceeded")
+	perfCmd.Flags().StringVar(&maxP90, "p90", "", "(optional) Maximum 90th percentile latency like 200ms, the command fails when it is exceeded")
+	perfCmd.Flags().StringVar(&maxP99, "p99", "", "(optional) Maximum 99th percentile latency like 500ms, the command fails when it is exceeded")
+	perfCmd.Flags().Float64Var(&maxErrorPercent, "max-error-rate", 0, "(optional) Maximum percentage of failed requests, the command fails when it is exceeded")
+	perfCmd.Flags().Float64Var(&minQPS, "min-qps", 0, "(optional) Minimum queries per second achieved, the command fails when it is not reached")
+	perfCmd.Flags().StringVar(&abortOn, "abort-on", "", "(optional) Comma separated status codes stopping the test as soon as they are received, -1 for socket errors")
+	perfCmd.Flags().Float64Var(&abortErrorPercent, "abort-on-error-rate", 0, "(optional) Percentage of failed requests over the abort window stopping the test")
+	perfCmd.Flags().StringVar(&abortLatency, "abort-on-latency", "", "(optional) 90th percentile latency over the abort window stopping the test like 2s")
+	perfCmd.Flags().StringVar(&abortWindow, "abort-window", "", "(optional) Window over which the error rate and the latency are checked like 30s")
+	perfCmd.Flags().StringVar(&testProfile, "profile", "", "(optional) ID of a test profile saved in Meshery to run, the test flags given along with it override the profile")
 	rootCmd.AddCommand(perfCmd)
 }
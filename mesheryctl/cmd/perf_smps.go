@@ -0,0 +1,38 @@
+// Copyright 2019 The Meshery Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build draft
+// +build draft
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/layer5io/meshery/models"
+)
+
+// checkSMPSFile parses the SMPS document of the file like Meshery does and prints each of its problems
+// with their line and field
+func checkSMPSFile(file string, data []byte) error {
+	_, err := models.ParseBenchmarkSpec(data)
+	verr, ok := err.(*models.SMPSValidationError)
+	if !ok {
+		return err
+	}
+	for _, e := range verr.Errors {
+		println(file + ": " + e.String())
+	}
+	return fmt.Errorf("%s is not a valid SMPS document", file)
+}
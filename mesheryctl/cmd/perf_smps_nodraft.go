@@ -0,0 +1,37 @@
+// Copyright 2019 The Meshery Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !draft
+// +build !draft
+
+package cmd
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// checkSMPSFile only checks that the file holds a YAML document, the models of Meshery parsing SMPS
+// need the draft build tag, so the document is then fully validated by Meshery only
+func checkSMPSFile(file string, data []byte) error {
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s is not a valid SMPS document: %v", file, err)
+	}
+	if len(doc) == 0 {
+		return fmt.Errorf("%s is empty", file)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 The Meshery Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	resultsCookie     = ""
+	resultsExportFile = ""
+)
+
+// resultsImportSummary is the outcome of an import reported by Meshery
+type resultsImportSummary struct {
+	Manifest *struct {
+		SchemaVersion int       `json:"schema_version"`
+		CreatedAt     time.Time `json:"created_at"`
+		Results       int       `json:"results"`
+	} `json:"manifest"`
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	Invalid  int `json:"invalid"`
+}
+
+// resultsCmd represents the results command
+var resultsCmd = &cobra.Command{
+	Use:   "results",
+	Short: "Export and import the results of the performance tests",
+	Long:  `Export the results stored by Meshery to an archive and import them back, on this or another machine.`,
+}
+
+// resultsExportCmd represents the results export command
+var resultsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all the results to an archive",
+	Long:  `Export all the results stored by Meshery, with their server metrics, to a gzipped tarball.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := http.NewRequest("GET", url+"/api/results/export", nil)
+		if err != nil {
+			println("Error in building the request")
+			os.Exit(1)
+		}
+		resp, err := doResultsRequest(req)
+		if err != nil {
+			println("Error: " + err.Error())
+			os.Exit(1)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		file := resultsExportFile
+		if len(file) == 0 {
+			file = "meshery-results-" + time.Now().Format("20060102-150405") + ".tar.gz"
+		}
+		out, err := os.Create(file)
+		if err != nil {
+			println("Error: unable to create the archive: " + err.Error())
+			os.Exit(1)
+		}
+		if _, err = io.Copy(out, resp.Body); err == nil {
+			err = out.Close()
+		}
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(file)
+			println("Error: unable to write the archive: " + err.Error())
+			os.Exit(1)
+		}
+		println("Results exported to " + file)
+	},
+}
+
+// resultsImportCmd represents the results import command
+var resultsImportCmd = &cobra.Command{
+	Use:   "import [archive]",
+	Short: "Import the results of an archive",
+	Long:  `Import the results of an archive made by the export command, the results Meshery already has are skipped.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		in, err := os.Open(args[0])
+		if err != nil {
+			println("Error: unable to read the archive: " + err.Error())
+			os.Exit(1)
+		}
+		defer func() {
+			_ = in.Close()
+		}()
+		req, err := http.NewRequest("POST", url+"/api/results/import", in)
+		if err != nil {
+			println("Error in building the request")
+			os.Exit(1)
+		}
+		req.Header.Set("Content-Type", "application/gzip")
+		resp, err := doResultsRequest(req)
+		if err != nil {
+			println("Error: " + err.Error())
+			os.Exit(1)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		summary := &resultsImportSummary{}
+		if err := json.NewDecoder(resp.Body).Decode(summary); err != nil {
+			println("Error: unable to read the outcome of the import: " + err.Error())
+			os.Exit(1)
+		}
+		if summary.Manifest != nil {
+			fmt.Printf("Archive of %d results made on %s\n", summary.Manifest.Results, summary.Manifest.CreatedAt.Format(time.RFC1123))
+		}
+		fmt.Printf("%d results imported, %d already present, %d invalid\n", summary.Imported, summary.Skipped, summary.Invalid)
+	},
+}
+
+// doResultsRequest sends the request with the provider cookie, the body of an unsuccessful response is returned as an error
+func doResultsRequest(req *http.Request) (*http.Response, error) {
+	cookieConf := strings.SplitN(resultsCookie, "=", 2)
+	if len(cookieConf) != 2 {
+		return nil, fmt.Errorf("invalid cookie %q, expecting name=value", resultsCookie)
+	}
+	req.AddCookie(&http.Cookie{Name: cookieConf[0], Value: cookieConf[1]})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach Meshery: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%s", strings.TrimSpace(buf.String()))
+	}
+	return resp, nil
+}
+
+func init() {
+	resultsCmd.PersistentFlags().StringVar(&resultsCookie, "cookie", "meshery-provider=Default Local Provider", "(required) identification of choice of provider.")
+	resultsExportCmd.Flags().StringVarP(&resultsExportFile, "output", "o", "", "(optional) File the archive is written to, meshery-results-<time>.tar.gz by default")
+	resultsCmd.AddCommand(resultsExportCmd)
+	resultsCmd.AddCommand(resultsImportCmd)
+	rootCmd.AddCommand(resultsCmd)
+}
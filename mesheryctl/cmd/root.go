@@ -40,6 +40,7 @@ Available Commands:
   help        Help about any command
   logs        Print logs
   perf        Performance testing and benchmarking
+  results     Export and import the results of the performance tests
   start       Start Meshery
   status      Check Meshery status
   stop        Stop Meshery
@@ -81,14 +82,14 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	fmt.Println("\n")
+	fmt.Print("\n\n")
 	//log formatter for improved UX
 	log.SetFormatter(new(TerminalFormatter))
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	fmt.Println("\n")
+	fmt.Print("\n\n")
 }
 
 func init() {
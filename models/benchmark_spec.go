@@ -1,6 +1,21 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/fgrpc"
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
 
 // Environment - represents a kubernetes environment
 type Environment struct {
@@ -10,10 +25,10 @@ type Environment struct {
 
 // MeshConfig - represents a service mesh config
 type MeshConfig struct {
-	MeshPolicyEnabled    bool `yaml:"mesh_policy_enabled,omitempty"`
-	MeshTelemetryEnabled bool `yaml:"mesh_telemetry_enabled,omitempty"`
-	MtlsEnabled          bool `yaml:"mtls_enabled,omitempty"`
-	ProxyConcurrency     int  `yaml:"proxy_concurrency,omitempty"`
+	MeshPolicyEnabled    bool `json:"mesh_policy_enabled,omitempty" yaml:"mesh_policy_enabled,omitempty"`
+	MeshTelemetryEnabled bool `json:"mesh_telemetry_enabled,omitempty" yaml:"mesh_telemetry_enabled,omitempty"`
+	MtlsEnabled          bool `json:"mtls_enabled,omitempty" yaml:"mtls_enabled,omitempty"`
+	ProxyConcurrency     int  `json:"proxy_concurrency,omitempty" yaml:"proxy_concurrency,omitempty"`
 }
 
 // MeshClientConfig - represents a load test client config
@@ -23,6 +38,31 @@ type MeshClientConfig struct {
 	Connections int          `yaml:"connections,omitempty"`
 	Rps         float64      `yaml:"rps,omitempty"`
 	LatenciesMs *LatenciesMs `yaml:"latencies_ms,omitempty"`
+	Request     *HTTPRequest `yaml:"request,omitempty"`
+	GRPC        *GRPCRequest `yaml:"grpc,omitempty"`
+	Arrival     string       `yaml:"arrival,omitempty"`
+}
+
+// GRPCRequest - represents the gRPC specific settings of the load test client, used when Protocol is grpc
+type GRPCRequest struct {
+	Streams       int           `yaml:"streams,omitempty"`
+	Ping          bool          `yaml:"ping,omitempty"`
+	PingDelay     time.Duration `yaml:"ping_delay,omitempty"`
+	HealthService string        `yaml:"health_service,omitempty"`
+}
+
+// HTTPRequest - represents how the requests sent by the load test client are shaped
+type HTTPRequest struct {
+	Method           string            `yaml:"method,omitempty"`
+	Headers          map[string]string `yaml:"headers,omitempty"`
+	ContentType      string            `yaml:"content_type,omitempty"`
+	Body             string            `yaml:"body,omitempty"`
+	BasicAuth        string            `yaml:"basic_auth,omitempty"`
+	BearerToken      string            `yaml:"bearer_token,omitempty"`
+	Timeout          time.Duration     `yaml:"timeout,omitempty"`
+	DisableKeepAlive bool              `yaml:"disable_keep_alive,omitempty"`
+	HTTP10           bool              `yaml:"http10,omitempty"`
+	Compression      bool              `yaml:"compression,omitempty"`
 }
 
 // LatenciesMs - represents a collection of important latencies
@@ -37,88 +77,284 @@ type LatenciesMs struct {
 
 // IngressGateway - holds ingress gateway info
 type IngressGateway struct {
-	Count     int     `yaml:"count,omitempty"`
-	CPUMCores float64 `yaml:"cpu_mCores,omitempty"`
-	MemMb     float64 `yaml:"mem_mb,omitempty"`
-	Rps       float64 `yaml:"rps,omitempty"`
-	Bps       float64 `yaml:"bps,omitempty"`
+	Count     int     `json:"count,omitempty" yaml:"count,omitempty"`
+	CPUMCores float64 `json:"cpu_mCores,omitempty" yaml:"cpu_mCores,omitempty"`
+	MemMb     float64 `json:"mem_mb,omitempty" yaml:"mem_mb,omitempty"`
+	Rps       float64 `json:"rps,omitempty" yaml:"rps,omitempty"`
+	Bps       float64 `json:"bps,omitempty" yaml:"bps,omitempty"`
 }
 
 // Sidecars - holds sidecars info
 type Sidecars struct {
-	Count     int     `yaml:"count,omitempty"`
-	CPUMCores float64 `yaml:"cpu_mCores,omitempty"`
-	MemMb     float64 `yaml:"mem_mb,omitempty"`
-	Rps       float64 `yaml:"rps,omitempty"`
-	Bps       float64 `yaml:"bps,omitempty"`
+	Count     int     `json:"count,omitempty" yaml:"count,omitempty"`
+	CPUMCores float64 `json:"cpu_mCores,omitempty" yaml:"cpu_mCores,omitempty"`
+	MemMb     float64 `json:"mem_mb,omitempty" yaml:"mem_mb,omitempty"`
+	Rps       float64 `json:"rps,omitempty" yaml:"rps,omitempty"`
+	Bps       float64 `json:"bps,omitempty" yaml:"bps,omitempty"`
 }
 
 // MeshTelemetry - holds overall Mesh info
 type MeshTelemetry struct {
-	Count     int     `yaml:"count,omitempty"`
-	CPUMCores float64 `yaml:"cpu_mCores,omitempty"`
-	MemMb     float64 `yaml:"mem_mb,omitempty"`
-	Rps       float64 `yaml:"rps,omitempty"`
+	Count     int     `json:"count,omitempty" yaml:"count,omitempty"`
+	CPUMCores float64 `json:"cpu_mCores,omitempty" yaml:"cpu_mCores,omitempty"`
+	MemMb     float64 `json:"mem_mb,omitempty" yaml:"mem_mb,omitempty"`
+	Rps       float64 `json:"rps,omitempty" yaml:"rps,omitempty"`
 }
 
 // MeshPolicy - holds MeshPolicy info
 type MeshPolicy struct {
-	Count        int     `yaml:"count,omitempty"`
-	CPUMCores    float64 `yaml:"cpu_mCores,omitempty"`
-	MemMb        float64 `yaml:"mem_mb,omitempty"`
-	Rps          float64 `yaml:"rps,omitempty"`
-	CacheHitRate float64 `yaml:"cache_hit_rate,omitempty"`
+	Count        int     `json:"count,omitempty" yaml:"count,omitempty"`
+	CPUMCores    float64 `json:"cpu_mCores,omitempty" yaml:"cpu_mCores,omitempty"`
+	MemMb        float64 `json:"mem_mb,omitempty" yaml:"mem_mb,omitempty"`
+	Rps          float64 `json:"rps,omitempty" yaml:"rps,omitempty"`
+	CacheHitRate float64 `json:"cache_hit_rate,omitempty" yaml:"cache_hit_rate,omitempty"`
 }
 
 // MeshControlPlane - holds control plan info
 type MeshControlPlane struct {
-	Count            int     `yaml:"count,omitempty"`
-	CPUMCores        float64 `yaml:"cpu_mCores,omitempty"`
-	MemMb            float64 `yaml:"mem_mb,omitempty"`
-	Endpoints        int     `yaml:"endpoints,omitempty"`
-	Services         int     `yaml:"services,omitempty"`
-	Sidecars         int     `yaml:"sidecars,omitempty"`
-	VirtualServices  int     `yaml:"virtual_services,omitempty"`
-	DestinationRules int     `yaml:"destination_rules,omitempty"`
-	LdsLatencyMs     float64 `yaml:"lds_latency_ms,omitempty"`
-	CdsLatencyMs     float64 `yaml:"cds_latency_ms,omitempty"`
+	Count            int     `json:"count,omitempty" yaml:"count,omitempty"`
+	CPUMCores        float64 `json:"cpu_mCores,omitempty" yaml:"cpu_mCores,omitempty"`
+	MemMb            float64 `json:"mem_mb,omitempty" yaml:"mem_mb,omitempty"`
+	Endpoints        int     `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+	Services         int     `json:"services,omitempty" yaml:"services,omitempty"`
+	Sidecars         int     `json:"sidecars,omitempty" yaml:"sidecars,omitempty"`
+	VirtualServices  int     `json:"virtual_services,omitempty" yaml:"virtual_services,omitempty"`
+	DestinationRules int     `json:"destination_rules,omitempty" yaml:"destination_rules,omitempty"`
+	LdsLatencyMs     float64 `json:"lds_latency_ms,omitempty" yaml:"lds_latency_ms,omitempty"`
+	CdsLatencyMs     float64 `json:"cds_latency_ms,omitempty" yaml:"cds_latency_ms,omitempty"`
 }
 
 // Workload - holds workload info
 type Workload struct {
-	Name      string  `yaml:"name,omitempty"`
-	Count     int     `yaml:"count,omitempty"`
-	CPUMCores float64 `yaml:"cpu_mCores,omitempty"`
-	MemMb     float64 `yaml:"mem_mb,omitempty"`
+	Name      string  `json:"name,omitempty" yaml:"name,omitempty"`
+	Count     int     `json:"count,omitempty" yaml:"count,omitempty"`
+	CPUMCores float64 `json:"cpu_mCores,omitempty" yaml:"cpu_mCores,omitempty"`
+	MemMb     float64 `json:"mem_mb,omitempty" yaml:"mem_mb,omitempty"`
 }
 
 // Metrics - holds overall metrics info
 type Metrics struct {
-	IngressGateway     *IngressGateway   `yaml:"ingress_gateway,omitempty"`
-	Sidecars           *Sidecars         `yaml:"sidecars,omitempty"`
-	MeshTelemetry      *MeshTelemetry    `yaml:"mesh_telemetry,omitempty"`
-	MeshPolicy         *MeshPolicy       `yaml:"mesh_policy,omitempty"`
-	MeshControlPlane   *MeshControlPlane `yaml:"mesh_control_plane,omitempty"`
-	IndividualWorkload *Workload         `yaml:"individual_workload_1,omitempty"`
+	IngressGateway     *IngressGateway   `json:"ingress_gateway,omitempty" yaml:"ingress_gateway,omitempty"`
+	Sidecars           *Sidecars         `json:"sidecars,omitempty" yaml:"sidecars,omitempty"`
+	MeshTelemetry      *MeshTelemetry    `json:"mesh_telemetry,omitempty" yaml:"mesh_telemetry,omitempty"`
+	MeshPolicy         *MeshPolicy       `json:"mesh_policy,omitempty" yaml:"mesh_policy,omitempty"`
+	MeshControlPlane   *MeshControlPlane `json:"mesh_control_plane,omitempty" yaml:"mesh_control_plane,omitempty"`
+	IndividualWorkload *Workload         `json:"individual_workload_1,omitempty" yaml:"individual_workload_1,omitempty"`
 }
 
 // BenchmarkSpec - represents SMPS
 type BenchmarkSpec struct {
-	StartTime    time.Time         `yaml:"start_time,omitempty"`
-	EndTime      time.Time         `yaml:"end_time,omitempty"`
-	MeshBuild    string            `yaml:"mesh_build,omitempty"`
-	ProxyBuild   string            `yaml:"proxy_build,omitempty"`
-	ExpGroupUUID string            `yaml:"exp_group_uuid,omitempty"`
-	ExpUUID      string            `yaml:"exp_uuid,omitempty"`
-	Profile      string            `yaml:"profile,omitempty"`
-	DetailsURI   string            `yaml:"details_uri,omitempty"`
-	EndpointURL  string            `yaml:"endpoint_url,omitempty"`
-	Env          *Environment      `yaml:"env,omitempty"`
-	Config       *MeshConfig       `yaml:"config,omitempty"`
-	Client       *MeshClientConfig `yaml:"client,omitempty"`
-	Metrics      *Metrics          `yaml:"metrics,omitempty"`
-}
-
-// func (b *BenchmarkSpec) ConvertToMesheryResult() *MesheryResult {
-
-// }
+	StartTime    time.Time           `yaml:"start_time,omitempty"`
+	EndTime      time.Time           `yaml:"end_time,omitempty"`
+	MeshBuild    string              `yaml:"mesh_build,omitempty"`
+	ProxyBuild   string              `yaml:"proxy_build,omitempty"`
+	ExpGroupUUID string              `yaml:"exp_group_uuid,omitempty"`
+	ExpUUID      string              `yaml:"exp_uuid,omitempty"`
+	Profile      string              `yaml:"profile,omitempty"`
+	DetailsURI   string              `yaml:"details_uri,omitempty"`
+	EndpointURL  string              `yaml:"endpoint_url,omitempty"`
+	Endpoints    []*LoadTestEndpoint `yaml:"endpoints,omitempty"`
+	Stages       []*LoadTestStage    `yaml:"stages,omitempty"`
+	Env          *Environment        `yaml:"env,omitempty"`
+	Config       *MeshConfig         `yaml:"config,omitempty"`
+	Client       *MeshClientConfig   `yaml:"client,omitempty"`
+	Thresholds   *LoadTestThresholds `yaml:"thresholds,omitempty"`
+
+	StopConditions *LoadTestStopConditions `yaml:"stop_conditions,omitempty"`
+	Metrics        *Metrics                `yaml:"metrics,omitempty"`
+}
+
+// Validate - checks that the spec describes a load test run which can be converted into a result
+func (b *BenchmarkSpec) Validate() error {
+	if b.StartTime.IsZero() {
+		return errors.New("the start time of the test is missing")
+	}
+	if len(b.Stages) == 0 && !b.EndTime.After(b.StartTime) {
+		return errors.New("the end time of the test must be after its start time")
+	}
+	if b.EndpointURL == "" && len(b.Endpoints) == 0 {
+		return errors.New("the endpoint of the test is missing")
+	}
+	if b.Client == nil || b.Client.LatenciesMs == nil {
+		return errors.New("the client latencies of the test are missing")
+	}
+	switch strings.ToLower(b.Client.Protocol) {
+	case "", "http", "https", "grpc":
+	default:
+		return fmt.Errorf("unsupported protocol: %s", b.Client.Protocol)
+	}
+	if b.Client.Connections < 0 || b.Client.Rps < 0 {
+		return errors.New("the connections and rps of the client must not be negative")
+	}
+	l := b.Client.LatenciesMs
+	prev := 0.0
+	for _, v := range []float64{l.Min, l.P50, l.P90, l.P99, l.Max} {
+		if v < 0 {
+			return errors.New("latencies must not be negative")
+		}
+		// the latencies which are not reported are left out
+		if v == 0 {
+			continue
+		}
+		if v < prev {
+			return errors.New("latencies must be ordered as min, p50, p90, p99 and max")
+		}
+		prev = v
+	}
+	if prev == 0 {
+		return errors.New("the client latencies of the test are missing")
+	}
+	if l.Average < 0 {
+		return errors.New("latencies must not be negative")
+	}
+	return nil
+}
+
+// ConvertToMesheryResult - converts SMP to a meshery result shaped like the ones of fortio,
+// the latency histogram is approximated from the reported latencies
+func (b *BenchmarkSpec) ConvertToMesheryResult() (*MesheryResult, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	endTime := b.EndTime
+	if len(b.Stages) > 0 && !endTime.After(b.StartTime) {
+		endTime = b.StartTime.Add(TotalDuration(b.Stages))
+	}
+	duration := endTime.Sub(b.StartTime)
+	url := b.EndpointURL
+	if url == "" {
+		url = b.Endpoints[0].URL
+	}
+
+	count := int64(math.Round(b.Client.Rps * duration.Seconds()))
+	if count < 1 {
+		count = 1
+	}
+	requestedQPS := "max"
+	if b.Client.Rps > 0 {
+		requestedQPS = strconv.FormatFloat(b.Client.Rps, 'f', -1, 64)
+	}
+	rr := periodic.RunnerResults{
+		RunType:           "HTTP",
+		Labels:            b.Profile,
+		StartTime:         b.StartTime,
+		RequestedQPS:      requestedQPS,
+		RequestedDuration: duration.String(),
+		ActualQPS:         b.Client.Rps,
+		ActualDuration:    duration,
+		NumThreads:        b.Client.Connections,
+		DurationHistogram: latenciesHistogram(b.Client.LatenciesMs, count),
+	}
+	// SMPS does not report errors, all the requests are counted as successful
+	var results interface{}
+	if strings.EqualFold(b.Client.Protocol, "grpc") {
+		grpcResults := &fgrpc.GRPCRunnerResults{
+			RunnerResults: rr,
+			Destination:   url,
+			RetCodes:      map[string]int64{"SERVING": count},
+		}
+		grpcResults.RunType = "GRPC Health"
+		if g := b.Client.GRPC; g != nil {
+			grpcResults.Streams = g.Streams
+			grpcResults.Ping = g.Ping
+			if g.Ping {
+				grpcResults.RunType = "GRPC Ping"
+			}
+		}
+		results = grpcResults
+	} else {
+		results = &fhttp.HTTPRunnerResults{
+			RunnerResults: rr,
+			URL:           url,
+			RetCodes:      map[int]int64{http.StatusOK: count},
+		}
+	}
+
+	// the result is kept as it is read back from the persisters
+	resJ, err := json.Marshal(results)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to convert the Benchmark Spec to a Meshery result")
+	}
+	resultsMap := map[string]interface{}{}
+	if err = json.Unmarshal(resJ, &resultsMap); err != nil {
+		return nil, errors.Wrap(err, "unable to convert the Benchmark Spec to a Meshery result")
+	}
+	extra := map[string]interface{}{}
+	if len(b.Stages) > 0 {
+		extra["stages"] = b.Stages
+	}
+	if len(b.Endpoints) > 0 {
+		extra["endpoints"] = b.Endpoints
+	}
+	if b.Client.Internal {
+		extra["InCluster"] = map[string]interface{}{}
+	}
+	if b.Env != nil && (b.Env.Kubernetes != "" || b.Env.NodeCount > 0) {
+		extra["kubernetes"] = map[string]interface{}{
+			"server_version": b.Env.Kubernetes,
+			"node_count":     b.Env.NodeCount,
+		}
+	}
+	if b.MeshBuild != "" {
+		extra["mesh_build"] = b.MeshBuild
+	}
+	if b.ProxyBuild != "" {
+		extra["proxy_build"] = b.ProxyBuild
+	}
+	if b.Config != nil {
+		extra["mesh_config"] = b.Config
+	}
+	if b.Metrics != nil {
+		extra["metrics"] = b.Metrics
+	}
+	if len(extra) > 0 {
+		bd, err := json.Marshal(extra)
+		if err == nil {
+			err = json.Unmarshal(bd, &resultsMap)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to convert the Benchmark Spec to a Meshery result")
+		}
+	}
+
+	return &MesheryResult{
+		ID:     uuid.FromStringOrNil(b.ExpUUID),
+		Name:   b.Profile,
+		Result: resultsMap,
+	}, nil
+}
+
+// latenciesHistogram builds a histogram in seconds, like the ones of fortio, whose buckets go through
+// the reported latencies so that its percentiles match them
+func latenciesHistogram(l *LatenciesMs, count int64) *stats.HistogramData {
+	points := []stats.Percentile{}
+	for _, p := range []stats.Percentile{
+		{Percentile: 0, Value: l.Min},
+		{Percentile: 50, Value: l.P50},
+		{Percentile: 90, Value: l.P90},
+		{Percentile: 99, Value: l.P99},
+		{Percentile: 100, Value: l.Max},
+	} {
+		if p.Value > 0 || (p.Percentile == 0 && l.Min == 0) {
+			points = append(points, stats.Percentile{Percentile: p.Percentile, Value: p.Value / 1000})
+		}
+	}
+	h := &stats.HistogramData{
+		Count: count,
+		Min:   points[0].Value,
+		Max:   points[len(points)-1].Value,
+		Avg:   l.Average / 1000,
+	}
+	for i := 1; i < len(points); i++ {
+		h.Data = append(h.Data, stats.Bucket{
+			Interval: stats.Interval{Start: points[i-1].Value, End: points[i].Value},
+			Percent:  points[i].Percentile,
+			Count:    int64(math.Round(float64(count) * (points[i].Percentile - points[i-1].Percentile) / 100)),
+		})
+		if p := points[i].Percentile; p > 0 && p < 100 {
+			h.Percentiles = append(h.Percentiles, points[i])
+		}
+	}
+	h.Sum = h.Avg * float64(count)
+	return h
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// BitCaskLoadTestJobPersister assists with persisting load test jobs in a Bitcask store
+type BitCaskLoadTestJobPersister struct {
+	fileName string
+	db       *bitcask.Bitcask
+}
+
+// NewBitCaskLoadTestJobPersister creates a new BitCaskLoadTestJobPersister instance
+func NewBitCaskLoadTestJobPersister(folderName string) (*BitCaskLoadTestJobPersister, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "jobDB")
+	db, err := bitcask.Open(fileName, bitcask.WithSync(true))
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	bd := &BitCaskLoadTestJobPersister{
+		fileName: fileName,
+		db:       db,
+	}
+	return bd, nil
+}
+
+// GetJobs - gets all the persisted jobs
+func (s *BitCaskLoadTestJobPersister) GetJobs() ([]*LoadTestJob, error) {
+	if s.db == nil {
+		return nil, errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	jobs := []*LoadTestJob{}
+	for k := range s.db.Keys() {
+		dd, err := s.db.Get(k)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		if len(dd) > 0 {
+			job := &LoadTestJob{}
+			if err := json.Unmarshal(dd, job); err != nil {
+				err = errors.Wrapf(err, "Unable to unmarshal data.")
+				logrus.Error(err)
+				return nil, err
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// WriteJob persists the job
+func (s *BitCaskLoadTestJobPersister) WriteJob(job *LoadTestJob) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+
+	if job == nil {
+		return errors.New("Given job data is nil.")
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal job data.")
+		logrus.Error(err)
+		return err
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Put(job.ID.Bytes(), data); err != nil {
+		err = errors.Wrapf(err, "Unable to persist job data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteJob removes the job with the given id
+func (s *BitCaskLoadTestJobPersister) DeleteJob(id uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Delete(id.Bytes()); err != nil {
+		err = errors.Wrapf(err, "Unable to delete job data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Compact merges the datafiles of the store, dropping the jobs deleted and the previous versions of the others,
+// it must not be called while the store is used
+func (s *BitCaskLoadTestJobPersister) Compact() error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+	if err := s.db.Merge(); err != nil {
+		err = errors.Wrapf(err, "Unable to compact the bitcask store")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// CloseJobPersister closes the bitcask store
+func (s *BitCaskLoadTestJobPersister) CloseJobPersister() {
+	if s.db == nil {
+		return
+	}
+	_ = s.db.Close()
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// BitCaskLoadTestSchedulePersister assists with persisting load test schedules in a Bitcask store
+type BitCaskLoadTestSchedulePersister struct {
+	fileName string
+	db       *bitcask.Bitcask
+}
+
+// NewBitCaskLoadTestSchedulePersister creates a new BitCaskLoadTestSchedulePersister instance
+func NewBitCaskLoadTestSchedulePersister(folderName string) (*BitCaskLoadTestSchedulePersister, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "scheduleDB")
+	db, err := bitcask.Open(fileName, bitcask.WithSync(true))
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	bd := &BitCaskLoadTestSchedulePersister{
+		fileName: fileName,
+		db:       db,
+	}
+	return bd, nil
+}
+
+// GetSchedules - gets all the persisted schedules
+func (s *BitCaskLoadTestSchedulePersister) GetSchedules() ([]*LoadTestSchedule, error) {
+	if s.db == nil {
+		return nil, errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	schedules := []*LoadTestSchedule{}
+	for k := range s.db.Keys() {
+		dd, err := s.db.Get(k)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		if len(dd) > 0 {
+			schedule := &LoadTestSchedule{}
+			if err := json.Unmarshal(dd, schedule); err != nil {
+				err = errors.Wrapf(err, "Unable to unmarshal data.")
+				logrus.Error(err)
+				return nil, err
+			}
+			schedules = append(schedules, schedule)
+		}
+	}
+	return schedules, nil
+}
+
+// WriteSchedule persists the schedule
+func (s *BitCaskLoadTestSchedulePersister) WriteSchedule(schedule *LoadTestSchedule) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+
+	if schedule == nil {
+		return errors.New("Given schedule data is nil.")
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal schedule data.")
+		logrus.Error(err)
+		return err
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Put(schedule.ID.Bytes(), data); err != nil {
+		err = errors.Wrapf(err, "Unable to persist schedule data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteSchedule removes the schedule with the given id
+func (s *BitCaskLoadTestSchedulePersister) DeleteSchedule(id uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Delete(id.Bytes()); err != nil {
+		err = errors.Wrapf(err, "Unable to delete schedule data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Compact merges the datafiles of the store, dropping the previous versions of the schedules,
+// it must not be called while the store is used
+func (s *BitCaskLoadTestSchedulePersister) Compact() error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+	if err := s.db.Merge(); err != nil {
+		err = errors.Wrapf(err, "Unable to compact the bitcask store")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// CloseSchedulePersister closes the bitcask store
+func (s *BitCaskLoadTestSchedulePersister) CloseSchedulePersister() {
+	if s.db == nil {
+		return
+	}
+	_ = s.db.Close()
+}
@@ -1,10 +1,13 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
 	"os"
 	"path"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
@@ -16,14 +19,30 @@ import (
 type BitCaskResultsPersister struct {
 	fileName string
 	db       *bitcask.Bitcask
+
+	// index holds what results are searched and ordered by, so that only the records of a page are read,
+	// it is persisted under the time index keys next to the results
+	index *resultsIndex
+
+	// storeLock keeps the store from being used while it is compacted, the file lock of bitcask only
+	// keeping other processes away
+	storeLock      sync.RWMutex
+	staleWrites    int64
+	lastCompaction time.Time
 }
 
+// resultsLastCompactionKey is the key of the time of the last compaction of the store
+var resultsLastCompactionKey = []byte("meta:last_compaction")
+
 // MesheryResultPage - represents a page of meshery results
 type MesheryResultPage struct {
 	Page       uint64           `json:"page"`
 	PageSize   uint64           `json:"page_size"`
 	TotalCount int              `json:"total_count"`
 	Results    []*MesheryResult `json:"results"`
+
+	// NextCursor - cursor of the next page when the results are listed from the newest one and there are more
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // NewBitCaskResultsPersister creates a new BitCaskResultsPersister instance
@@ -52,14 +71,118 @@ func NewBitCaskResultsPersister(folderName string) (*BitCaskResultsPersister, er
 		fileName: fileName,
 		db:       db,
 	}
+	if err := bd.loadIndex(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if data, err := db.Get(resultsLastCompactionKey); err == nil {
+		bd.lastCompaction, _ = time.Parse(time.RFC3339Nano, string(data))
+	}
 	return bd, nil
 }
 
-// GetResults - gets result for the page and pageSize
-func (s *BitCaskResultsPersister) GetResults(page, pageSize uint64) ([]byte, error) {
+// loadIndex loads the time index of the results and brings it in line with them, the results written before
+// the index existed or while it could not be updated are read and indexed then
+func (s *BitCaskResultsPersister) loadIndex() error {
+	entries := map[uuid.UUID]*resultIndexEntry{}
+	resultKeys := [][]byte{}
+	// the keys are collected first as the store can not be read while they are listed
+	indexKeys := [][]byte{}
+	for k := range s.db.Keys() {
+		switch {
+		case bytes.HasPrefix(k, resultsTimeIndexPrefix):
+			indexKeys = append(indexKeys, k)
+		case len(k) == uuid.Size:
+			resultKeys = append(resultKeys, k)
+		}
+	}
+	stale := [][]byte{}
+	for _, k := range indexKeys {
+		data, err := s.db.Get(k)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to read data from bitcask store")
+		}
+		e, err := parseResultIndexEntry(k, data)
+		if err != nil || entries[e.ID] != nil {
+			stale = append(stale, k)
+			continue
+		}
+		entries[e.ID] = e
+	}
+
+	results := map[uuid.UUID]bool{}
+	migrated := 0
+	for _, k := range resultKeys {
+		id := uuid.FromBytesOrNil(k)
+		results[id] = true
+		if entries[id] != nil {
+			continue
+		}
+		data, err := s.db.Get(k)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to read data from bitcask store")
+		}
+		e, err := newResultIndexEntry(id, time.Time{}, data)
+		if err != nil {
+			logrus.Warnf("skipping unreadable result %s: %v", id, err)
+			continue
+		}
+		// the results did not record when they were created, they are ordered by when their test started
+		e.CreatedAt = e.StartTime
+		e.key = resultIndexKey(e.CreatedAt, id)
+		if err := s.putIndexEntry(e); err != nil {
+			return err
+		}
+		entries[id] = e
+		migrated++
+	}
+	for id, e := range entries {
+		if !results[id] {
+			stale = append(stale, e.key)
+			delete(entries, id)
+		}
+	}
+	for _, k := range stale {
+		if err := s.db.Delete(k); err != nil {
+			return errors.Wrapf(err, "Unable to delete stale index data from bitcask store")
+		}
+	}
+	s.staleWrites += int64(len(stale))
+	if migrated > 0 {
+		logrus.Infof("added %d results to the results index", migrated)
+	}
+
+	list := make([]*resultIndexEntry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e)
+	}
+	s.index = newResultsIndex(list)
+	logrus.Debugf("indexed %d results, removed %d stale index entries", len(list), len(stale))
+	return nil
+}
+
+// putIndexEntry persists the index entry of a result
+func (s *BitCaskResultsPersister) putIndexEntry(e *resultIndexEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to marshal index data.")
+	}
+	if err := s.db.Put(e.key, data); err != nil {
+		return errors.Wrapf(err, "Unable to persist index data.")
+	}
+	return nil
+}
+
+// GetResults - gets the page of the results matching the filter, all the results from the most recent one when it is nil
+func (s *BitCaskResultsPersister) GetResults(page, pageSize uint64, filter *ResultsFilter) ([]byte, error) {
 	if s.db == nil {
 		return nil, errors.New("Connection to DB does not exist.")
 	}
+	if filter == nil {
+		filter, _ = ParseResultsFilter(nil, "", "")
+	}
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
 
 RETRY:
 	locked, err := s.db.TryRLock()
@@ -74,46 +197,37 @@ RETRY:
 		_ = s.db.Unlock()
 	}()
 
-	total := s.db.Len()
-
-	results := []*MesheryResult{}
-
-	start := page * pageSize
-	end := (page+1)*pageSize - 1
-	logrus.Debugf("received page: %d, page size: %d, total: %d", page, pageSize, total)
-	logrus.Debugf("computed start index: %d, end index: %d", start, end)
-
-	if start > uint64(total) {
-		return nil, fmt.Errorf("index out of range")
+	logrus.Debugf("received page: %d, page size: %d, cursor: %x", page, pageSize, filter.Cursor)
+	p, err := s.index.page(page, pageSize, filter)
+	if err != nil {
+		return nil, err
 	}
-	var localIndex uint64
 
-	for k := range s.db.Keys() {
-		if localIndex >= start && localIndex <= end {
-			dd, err := s.db.Get(k)
-			if err != nil {
-				err = errors.Wrapf(err, "Unable to read data from bitcask store")
+	results := []*MesheryResult{}
+	for _, k := range p.ids {
+		dd, err := s.db.Get(k.Bytes())
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		if len(dd) > 0 {
+			result := &MesheryResult{}
+			if err := json.Unmarshal(dd, result); err != nil {
+				err = errors.Wrapf(err, "Unable to unmarshal data.")
 				logrus.Error(err)
 				return nil, err
 			}
-			if len(dd) > 0 {
-				result := &MesheryResult{}
-				if err := json.Unmarshal(dd, result); err != nil {
-					err = errors.Wrapf(err, "Unable to unmarshal data.")
-					logrus.Error(err)
-					return nil, err
-				}
-				results = append(results, result)
-			}
+			results = append(results, result)
 		}
-		localIndex++
 	}
 
 	bd, err := json.Marshal(&MesheryResultPage{
 		Page:       page,
 		PageSize:   pageSize,
-		TotalCount: total,
+		TotalCount: p.total,
 		Results:    results,
+		NextCursor: p.nextCursor,
 	})
 	if err != nil {
 		err = errors.Wrapf(err, "Unable to marshal result data.")
@@ -129,6 +243,8 @@ func (s *BitCaskResultsPersister) GetResult(key uuid.UUID) (*MesheryResult, erro
 	if s.db == nil {
 		return nil, errors.New("Connection to DB does not exist.")
 	}
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
 
 RETRY:
 	locked, err := s.db.TryRLock()
@@ -145,7 +261,7 @@ RETRY:
 
 	keyb := key.Bytes()
 	if !s.db.Has(keyb) {
-		err = errors.New("given key not found")
+		err = ErrResultNotFound
 		logrus.Error(err)
 		return nil, err
 	}
@@ -177,6 +293,8 @@ func (s *BitCaskResultsPersister) WriteResult(key uuid.UUID, result []byte) erro
 	if result == nil {
 		return errors.New("Given result data is nil.")
 	}
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
 
 RETRY:
 	locked, err := s.db.TryLock()
@@ -191,14 +309,215 @@ RETRY:
 		_ = s.db.Unlock()
 	}()
 
+	return s.writeResult(key, result, time.Now())
+}
+
+// UpdateResult applies the update to the result with the given key and persists it, the result being read and
+// written under the write lock of the store so that concurrent updates and deletions are not lost
+func (s *BitCaskResultsPersister) UpdateResult(key uuid.UUID, update func(*MesheryResult)) (*MesheryResult, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	keyb := key.Bytes()
+	if !s.db.Has(keyb) {
+		return nil, ErrResultNotFound
+	}
+	data, err := s.db.Get(keyb)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to fetch result data")
+		logrus.Error(err)
+		return nil, err
+	}
+	result := &MesheryResult{}
+	if err := json.Unmarshal(data, result); err != nil {
+		err = errors.Wrapf(err, "Unable to marshal result data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	update(result)
+	if data, err = json.Marshal(result); err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the result")
+	}
+	if err := s.writeResult(key, data, time.Now()); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportResult persists a result created at the given time unless there is already a result with its key,
+// it tells whether the result was written
+func (s *BitCaskResultsPersister) ImportResult(key uuid.UUID, createdAt time.Time, result []byte) (bool, error) {
+	if s.db == nil {
+		return false, errors.New("connection to DB does not exist")
+	}
+
+	if result == nil {
+		return false, errors.New("Given result data is nil.")
+	}
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if s.db.Has(key.Bytes()) {
+		return false, nil
+	}
+	if err := s.writeResult(key, result, createdAt); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeResult persists and indexes a result, a result which is written again keeping its creation time
+func (s *BitCaskResultsPersister) writeResult(key uuid.UUID, result []byte, createdAt time.Time) error {
 	if err := s.db.Put(key.Bytes(), result); err != nil {
 		err = errors.Wrapf(err, "Unable to persist result data.")
 		logrus.Error(err)
 		return err
 	}
+
+	// a result which is written again keeps its place in the index
+	old := s.index.get(key)
+	if old != nil {
+		createdAt = old.CreatedAt
+	}
+	e, err := newResultIndexEntry(key, createdAt, result)
+	if err != nil {
+		// the result is still readable by its key
+		logrus.Warnf("unable to index result %s: %v", key, err)
+		return nil
+	}
+	if old != nil {
+		// the previous record and index entry are left for the compaction
+		atomic.AddInt64(&s.staleWrites, 2)
+	}
+	if old != nil && !bytes.Equal(old.key, e.key) {
+		if err := s.db.Delete(old.key); err != nil {
+			logrus.Warnf("unable to delete the index entry of result %s: %v", key, err)
+		}
+	}
+	if err := s.putIndexEntry(e); err != nil {
+		// the index is brought in line with the results when the store is opened again
+		logrus.Warnf("unable to index result %s: %v", key, err)
+	}
+	s.index.put(e)
 	return nil
 }
 
+// DeleteResult deletes the result and its index entry
+func (s *BitCaskResultsPersister) DeleteResult(key uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if !s.db.Has(key.Bytes()) {
+		return ErrResultNotFound
+	}
+	// the result goes first, a stale index entry being removed when the store is opened again
+	if err := s.db.Delete(key.Bytes()); err != nil {
+		err = errors.Wrapf(err, "Unable to delete result data.")
+		logrus.Error(err)
+		return err
+	}
+	atomic.AddInt64(&s.staleWrites, 1)
+	if e := s.index.remove(key); e != nil {
+		if err := s.db.Delete(e.key); err != nil {
+			logrus.Warnf("unable to delete the index entry of result %s: %v", key, err)
+		}
+		atomic.AddInt64(&s.staleWrites, 1)
+	}
+	return nil
+}
+
+// Compact merges the datafiles of the store, reclaiming the space of the deleted and overwritten results
+func (s *BitCaskResultsPersister) Compact() error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+	s.storeLock.Lock()
+	defer s.storeLock.Unlock()
+
+	if err := s.db.Merge(); err != nil {
+		err = errors.Wrapf(err, "Unable to compact the bitcask store")
+		logrus.Error(err)
+		return err
+	}
+	s.lastCompaction = time.Now()
+	atomic.StoreInt64(&s.staleWrites, 0)
+	if err := s.db.Put(resultsLastCompactionKey, []byte(s.lastCompaction.Format(time.RFC3339Nano))); err != nil {
+		logrus.Warnf("unable to persist the time of the compaction: %v", err)
+	}
+	return nil
+}
+
+// Stats returns the size of the store, how many results it holds and when it was last compacted
+func (s *BitCaskResultsPersister) Stats() (*ResultsStoreStatus, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
+
+	stats, err := s.db.Stats()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to read the stats of the bitcask store")
+	}
+	status := &ResultsStoreStatus{
+		SizeBytes:   stats.Size,
+		Datafiles:   stats.Datafiles,
+		Keys:        stats.Keys,
+		Results:     s.index.len(),
+		StaleWrites: int(atomic.LoadInt64(&s.staleWrites)),
+	}
+	if !s.lastCompaction.IsZero() {
+		t := s.lastCompaction
+		status.LastCompaction = &t
+	}
+	return status, nil
+}
+
 // CloseResultPersister closes the badger store
 func (s *BitCaskResultsPersister) CloseResultPersister() {
 	if s.db == nil {
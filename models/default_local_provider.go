@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/gorilla/sessions"
@@ -140,7 +142,12 @@ func (l *DefaultLocalProvider) FetchResults(req *http.Request, page, pageSize, s
 		logrus.Error(err)
 		return nil, err
 	}
-	return l.ResultPersister.GetResults(pg, pgs)
+	filter, err := ParseResultsFilter(req, search, order)
+	if err != nil {
+		logrus.Error(err)
+		return nil, &ResultsFilterError{Err: err}
+	}
+	return l.ResultPersister.GetResults(pg, pgs, filter)
 }
 
 // GetResult - fetches result from provider backend for the given result id
@@ -152,6 +159,51 @@ func (l *DefaultLocalProvider) GetResult(req *http.Request, resultID uuid.UUID)
 	return l.ResultPersister.GetResult(resultID)
 }
 
+// DeleteResult - deletes the result with the given id from the local store
+func (l *DefaultLocalProvider) DeleteResult(req *http.Request, resultID uuid.UUID) error {
+	if resultID == uuid.Nil {
+		return fmt.Errorf("given resultID is not valid")
+	}
+	return l.ResultPersister.DeleteResult(resultID)
+}
+
+// UpdateResult - renames, tags or annotates the result with the given id in the local store
+func (l *DefaultLocalProvider) UpdateResult(req *http.Request, resultID uuid.UUID, update *ResultUpdate) (*MesheryResult, error) {
+	if resultID == uuid.Nil {
+		return nil, fmt.Errorf("given resultID is not valid")
+	}
+	return l.ResultPersister.UpdateResult(resultID, update.Apply)
+}
+
+// ExportResults - writes an archive of all the results of the local store
+func (l *DefaultLocalProvider) ExportResults(req *http.Request, w io.Writer) (*ResultsArchiveManifest, error) {
+	return l.ResultPersister.ExportResults(w)
+}
+
+// ImportResults - imports the results of an archive into the local store
+func (l *DefaultLocalProvider) ImportResults(req *http.Request, r io.Reader) (*ResultsImportSummary, error) {
+	return l.ResultPersister.ImportResults(r)
+}
+
+// ImportResult - persists a result which was not run by Meshery, like one converted from SMPS, in the local
+// store only, regardless of sharing results
+func (l *DefaultLocalProvider) ImportResult(req *http.Request, result *MesheryResult) (string, error) {
+	key, err := uuid.NewV4()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to generate an id for the result")
+	}
+	result.ID = key
+	data, err := json.Marshal(result)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "error - unable to marshal meshery result for persisting"))
+		return "", err
+	}
+	if _, err := l.ResultPersister.ImportResult(key, time.Now(), data); err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
 // PublishResults - publishes results to the provider backend syncronously
 func (l *DefaultLocalProvider) PublishResults(req *http.Request, result *MesheryResult) (string, error) {
 	data, err := json.Marshal(result)
@@ -1,10 +1,12 @@
 package models
 
 import (
+	"context"
 	"net/http"
 
 	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/gorilla/sessions"
 	"github.com/vmihailenco/taskq"
 )
@@ -30,9 +32,23 @@ type HandlerInterface interface {
 
 	LoadTestHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 	LoadTestUsingSMPSHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ValidateSMPSHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestJobsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestJobHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestJobStreamHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestProfilesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestProfileHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestSchedulesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestScheduleHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	RunScheduledLoadTest(ctx context.Context, schedule *LoadTestSchedule) (uuid.UUID, error)
 	CollectStaticMetrics(config *SubmitMetricsConfig) error
 	FetchResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
-	GetResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	CompareResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ImportSMPSResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ExportResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ImportResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ResultsStoreHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 
 	MeshAdapterConfigHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 	MeshOpsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
@@ -56,6 +72,7 @@ type HandlerInterface interface {
 	SaveSelectedPrometheusBoardsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 
 	LoadTestPrefencesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestCertsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 	AnonymousStatsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 
 	SessionSyncHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
@@ -74,6 +91,17 @@ type HandlerConfig struct {
 	AdapterTracker AdaptersTrackerInterface
 	QueryTracker   QueryTrackerInterface
 
+	LoadTestJobTracker LoadTestJobTrackerInterface
+	LoadGenerators     LoadGeneratorRegistryInterface
+	// LoadTestCoordinator - when set, load tests are distributed to its workers instead of running in process
+	LoadTestCoordinator LoadTestCoordinatorInterface
+	// LoadTestJobImage - image of the Kubernetes Jobs running in-cluster load tests
+	LoadTestJobImage string
+	// LoadTestScheduler - runs the scheduled load tests
+	LoadTestScheduler LoadTestSchedulerInterface
+	// ResultsJanitor - enforces the retention of the results stored locally
+	ResultsJanitor ResultsJanitorInterface
+
 	Queue taskq.Queue
 
 	KubeConfigFolder string
@@ -2,9 +2,13 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"fortio.org/fortio/fgrpc"
 	"fortio.org/fortio/fhttp"
 	"fortio.org/fortio/periodic"
 	"github.com/gofrs/uuid"
@@ -21,6 +25,9 @@ const (
 
 	// Wrk2LG - represents the wrk2 load generator
 	Wrk2LG LoadGenerator = "wrk2"
+
+	// NativeLG - represents the built-in open model load generator
+	NativeLG LoadGenerator = "native"
 )
 
 // Name - retrieves a string value for the generator
@@ -28,6 +35,36 @@ func (l LoadGenerator) Name() string {
 	return string(l)
 }
 
+// LoadTestArrival - represents how the requests of an open model load test are spread over time
+type LoadTestArrival string
+
+const (
+	// ConstantArrival - requests are sent at a fixed interval
+	ConstantArrival LoadTestArrival = "constant"
+
+	// PoissonArrival - requests are sent following a Poisson process, with exponentially distributed intervals
+	PoissonArrival LoadTestArrival = "poisson"
+)
+
+// ParseLoadTestArrival - parses the arrival, defaulting to a constant one
+func ParseLoadTestArrival(s string) (LoadTestArrival, error) {
+	switch a := LoadTestArrival(strings.ToLower(s)); a {
+	case "":
+		return ConstantArrival, nil
+	case ConstantArrival, PoissonArrival:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid arrival: %s", s)
+	}
+}
+
+// InClusterOptions - represents where an in-cluster load test is run
+type InClusterOptions struct {
+	Namespace string `json:"namespace,omitempty"`
+	// InjectSidecar - whether the service mesh proxy is injected in the load generator pod
+	InjectSidecar bool `json:"inject_sidecar,omitempty"`
+}
+
 // LoadTestOptions represents the load test options
 type LoadTestOptions struct {
 	Name string
@@ -42,6 +79,38 @@ type LoadTestOptions struct {
 
 	LoadGenerator LoadGenerator
 
+	Method  string
+	Headers map[string]string
+
+	ContentType     string
+	Payload         []byte
+	UserCredentials string // user:password used for basic authentication
+	BearerToken     string
+
+	HTTPReqTimeout   time.Duration
+	DisableKeepAlive bool
+	HTTP10           bool
+	Compression      bool
+
+	// Endpoints - when present, all the endpoints are tested concurrently instead of URL
+	Endpoints []*LoadTestEndpoint
+
+	// Stages - when present, the stages are run one after the other instead of a constant HTTPQPS for Duration
+	Stages []*LoadTestStage
+
+	// InCluster - when present, the load generator is run as a Kubernetes Job instead of in process
+	InCluster *InClusterOptions
+
+	// StopConditions - when present, the test ends early once one of them is met and the partial result is kept
+	StopConditions *LoadTestStopConditions
+
+	// Thresholds - when present, the results are checked against them once the test is over
+	Thresholds *LoadTestThresholds
+
+	// Arrival - distribution of the request start times for the generators using an open model
+	Arrival LoadTestArrival
+
+	// Cert, Key, CACert - PEM encoded client certificate, its key and the CA certificates used to verify the service
 	Cert, Key, CACert string
 
 	AllowInitialErrors bool
@@ -54,6 +123,136 @@ type LoadTestOptions struct {
 	GRPCPingDelay    time.Duration
 }
 
+// LoadTestEndpoint - represents one of the endpoints of a multi-endpoint scenario
+type LoadTestEndpoint struct {
+	Name    string            `json:"name,omitempty" yaml:"name,omitempty"`
+	URL     string            `json:"url" yaml:"url"`
+	Weight  int               `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// Validate - validates the endpoint and fills in the defaults
+func (e *LoadTestEndpoint) Validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || !u.IsAbs() {
+		return fmt.Errorf("invalid url for endpoint: %s", e.URL)
+	}
+	if e.Weight < 0 {
+		return fmt.Errorf("invalid weight for endpoint %s: %d", e.URL, e.Weight)
+	}
+	if e.Weight == 0 {
+		e.Weight = 1
+	}
+	if e.Name == "" {
+		e.Name = e.URL
+	}
+	e.Method = strings.ToUpper(e.Method)
+	return nil
+}
+
+// LoadTestStageType - represents the shape of the load in a stage
+type LoadTestStageType string
+
+const (
+	// ConstantStage - represents a stage running at a constant QPS
+	ConstantStage LoadTestStageType = "constant"
+
+	// RampStage - represents a stage moving linearly from the QPS of the previous stage to its own QPS
+	RampStage LoadTestStageType = "ramp"
+
+	// SpikeStage - represents a short stage with a burst of QPS
+	SpikeStage LoadTestStageType = "spike"
+
+	// SoakStage - represents a long stage running at a constant QPS
+	SoakStage LoadTestStageType = "soak"
+)
+
+// LoadTestStage - represents a stage of a staged load profile
+type LoadTestStage struct {
+	Name     string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Type     LoadTestStageType `json:"type,omitempty" yaml:"type,omitempty"`
+	Duration time.Duration     `json:"duration" yaml:"duration"`
+	QPS      float64           `json:"qps" yaml:"qps"`
+	Threads  int               `json:"threads,omitempty" yaml:"threads,omitempty"`
+}
+
+// Validate - validates the stage and fills in the defaults
+func (st *LoadTestStage) Validate() error {
+	switch st.Type {
+	case "":
+		st.Type = ConstantStage
+	case ConstantStage, RampStage, SpikeStage, SoakStage:
+	default:
+		return fmt.Errorf("invalid stage type: %s", st.Type)
+	}
+	if st.Duration <= 0 {
+		return fmt.Errorf("invalid duration for stage: %s", st.Duration)
+	}
+	if st.QPS < 0 {
+		return fmt.Errorf("invalid qps for stage: %g", st.QPS)
+	}
+	if st.Threads < 0 {
+		return fmt.Errorf("invalid threads for stage: %d", st.Threads)
+	}
+	return nil
+}
+
+// TotalDuration - returns the time needed to run all the stages
+func TotalDuration(stages []*LoadTestStage) time.Duration {
+	var total time.Duration
+	for _, stage := range stages {
+		total += stage.Duration
+	}
+	return total
+}
+
+// ParseLoadTestStages - parses stages given as a comma separated list of [type:]duration:qps,
+// ex: ramp:1m:100,5m:100,spike:10s:500,soak:1h:50
+func ParseLoadTestStages(stages string) ([]*LoadTestStage, error) {
+	result := []*LoadTestStage{}
+	for _, st := range strings.Split(stages, ",") {
+		parts := strings.Split(strings.TrimSpace(st), ":")
+		stage := &LoadTestStage{}
+		switch len(parts) {
+		case 2:
+		case 3:
+			stage.Type = LoadTestStageType(strings.ToLower(parts[0]))
+			parts = parts[1:]
+		default:
+			return nil, fmt.Errorf("invalid stage: %s, expecting [type:]duration:qps", st)
+		}
+		var err error
+		if stage.Duration, err = time.ParseDuration(parts[0]); err != nil {
+			return nil, fmt.Errorf("invalid duration for stage %s: %v", st, err)
+		}
+		if stage.QPS, err = strconv.ParseFloat(parts[1], 64); err != nil {
+			return nil, fmt.Errorf("invalid qps for stage %s: %v", st, err)
+		}
+		result = append(result, stage)
+	}
+	if err := ValidateLoadTestStages(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ValidateLoadTestStages - validates all the stages and names the unnamed ones after their position
+func ValidateLoadTestStages(stages []*LoadTestStage) error {
+	for i, stage := range stages {
+		if stage == nil {
+			return fmt.Errorf("invalid stage at position %d", i+1)
+		}
+		if err := stage.Validate(); err != nil {
+			return err
+		}
+		if stage.Name == "" {
+			stage.Name = fmt.Sprintf("stage-%d", i+1)
+		}
+	}
+	return nil
+}
+
 // LoadTestStatus - used for representing load test status
 type LoadTestStatus string
 
@@ -66,6 +265,9 @@ const (
 
 	// LoadTestSuccess - represents a success status
 	LoadTestSuccess LoadTestStatus = "success"
+
+	// LoadTestProgress - represents a snapshot of the live metrics of a running test
+	LoadTestProgress LoadTestStatus = "progress"
 )
 
 // LoadTestResponse - used to bundle the response with status to the client
@@ -73,6 +275,31 @@ type LoadTestResponse struct {
 	Status  LoadTestStatus `json:"status,omitempty"`
 	Message string         `json:"message,omitempty"`
 	Result  *MesheryResult `json:"result,omitempty"`
+	JobID   string         `json:"job_id,omitempty"`
+
+	Verdict *LoadTestVerdict `json:"verdict,omitempty"`
+
+	Snapshot *LoadTestSnapshot `json:"snapshot,omitempty"`
+}
+
+// LoadTestSnapshot - represents the live metrics of a running load test.
+// The latencies and the error rate are computed over the last WindowSeconds, the counts since the start.
+type LoadTestSnapshot struct {
+	Time            time.Time `json:"time"`
+	ElapsedSeconds  float64   `json:"elapsed_seconds"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	// Partial - only the elapsed time is known as the load generator does not report the requests as they complete
+	Partial bool `json:"partial,omitempty"`
+
+	Count         int64         `json:"count"`
+	Errors        int64         `json:"errors"`
+	RetCodes      map[int]int64 `json:"ret_codes,omitempty"`
+	QPS           float64       `json:"qps"`
+	WindowSeconds int           `json:"window_seconds,omitempty"`
+	P50Ms         float64       `json:"p50_ms"`
+	P90Ms         float64       `json:"p90_ms"`
+	P99Ms         float64       `json:"p99_ms"`
+	ErrorPercent  float64       `json:"error_percent"`
 }
 
 // MesheryResult - represents the results from Meshery test run to be shipped
@@ -82,10 +309,31 @@ type MesheryResult struct {
 	Mesh   string                 `json:"mesh,omitempty"`
 	Result map[string]interface{} `json:"runner_results,omitempty"`
 
+	// Verdict - outcome of the thresholds of the test, when it had any
+	Verdict *LoadTestVerdict `json:"verdict,omitempty"`
+
+	// Tags and Notes - annotations of the result by the user
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+
 	ServerMetrics     interface{} `json:"server_metrics,omitempty"`
 	ServerBoardConfig interface{} `json:"server_board_config,omitempty"`
 }
 
+// StartTime - returns the time the load test started, zero when the result does not have it
+func (m *MesheryResult) StartTime() time.Time {
+	s, _ := m.Result["StartTime"].(string)
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// AbortReason - returns why the load test was stopped before its end, empty when it ran till the end
+func (m *MesheryResult) AbortReason() string {
+	aborted, _ := m.Result["Aborted"].(map[string]interface{})
+	reason, _ := aborted["reason"].(string)
+	return reason
+}
+
 // ConvertToSpec - converts meshery result to SMP
 func (m *MesheryResult) ConvertToSpec() (*BenchmarkSpec, error) {
 	b := &BenchmarkSpec{
@@ -97,36 +345,49 @@ func (m *MesheryResult) ConvertToSpec() (*BenchmarkSpec, error) {
 	var (
 		results periodic.HasRunnerResult
 	)
-	retcodesString, _ := m.Result["RetCodes"].(map[string]interface{})
-	logrus.Debugf("retcodes: %+v, %T", m.Result["RetCodes"], m.Result["RetCodes"])
-	retcodes := map[int]int64{}
-	for k, v := range retcodesString {
-		k1, _ := strconv.Atoi(k)
-		retcodes[k1], _ = v.(int64)
-	}
-	// retcodes[200] = 10
-	m.Result["RetCodes"] = retcodes
 	logrus.Debugf("result to be converted: %+v", m)
-	if m.Result["RunType"].(string) == "HTTP" {
+	runType, _ := m.Result["RunType"].(string)
+	resJ, err := json.Marshal(m.Result)
+	if err != nil {
+		err = errors.Wrap(err, "unable while converting Meshery result to Benchmark Spec")
+		logrus.Error(err)
+		return nil, err
+	}
+	if runType == "HTTP" {
 		httpResults := &fhttp.HTTPRunnerResults{}
-		resJ, err := json.Marshal(m.Result)
+		err = json.Unmarshal(resJ, httpResults)
 		if err != nil {
 			err = errors.Wrap(err, "unable while converting Meshery result to Benchmark Spec")
 			logrus.Error(err)
 			return nil, err
 		}
-		err = json.Unmarshal(resJ, httpResults)
+
+		results = httpResults
+		logrus.Debugf("httpresults: %+v", httpResults)
+		b.EndpointURL = httpResults.URL
+		b.Client.Protocol = "http"
+	} else if strings.HasPrefix(runType, "GRPC") {
+		// fortio reports either "GRPC Ping" or "GRPC Health"
+		grpcResults := &fgrpc.GRPCRunnerResults{}
+		err = json.Unmarshal(resJ, grpcResults)
 		if err != nil {
 			err = errors.Wrap(err, "unable while converting Meshery result to Benchmark Spec")
 			logrus.Error(err)
 			return nil, err
 		}
 
-		results = httpResults
-		logrus.Debugf("httpresults: %+v", httpResults)
-		b.EndpointURL = httpResults.URL
+		results = grpcResults
+		logrus.Debugf("grpcresults: %+v", grpcResults)
+		b.EndpointURL = grpcResults.Destination
+		b.Client.Protocol = "grpc"
+		b.Client.GRPC = &GRPCRequest{
+			Streams: grpcResults.Streams,
+			Ping:    grpcResults.Ping,
+		}
 	} else {
-		// TODO: GRPC
+		err = fmt.Errorf("unsupported run type: %s", runType)
+		logrus.Error(err)
+		return nil, err
 	}
 
 	result := results.Result()
@@ -134,20 +395,47 @@ func (m *MesheryResult) ConvertToSpec() (*BenchmarkSpec, error) {
 	b.EndTime = result.StartTime.Add(result.ActualDuration)
 	b.Client.Connections = result.NumThreads
 	b.Client.Rps = result.ActualQPS
-	b.Client.Internal = false
+	// in-cluster load tests record where they ran in the results
+	_, b.Client.Internal = m.Result["InCluster"]
+	// the histograms of fortio are in seconds
 	b.Client.LatenciesMs = &LatenciesMs{
-		Min:     result.DurationHistogram.Min,
-		Max:     result.DurationHistogram.Max,
-		Average: result.DurationHistogram.Avg,
+		Min:     result.DurationHistogram.Min * 1000,
+		Max:     result.DurationHistogram.Max * 1000,
+		Average: result.DurationHistogram.Avg * 1000,
 	}
 	for _, p := range result.DurationHistogram.Percentiles {
 		switch p.Percentile {
 		case 50:
-			b.Client.LatenciesMs.P50 = p.Value
+			b.Client.LatenciesMs.P50 = p.Value * 1000
 		case 90:
-			b.Client.LatenciesMs.P90 = p.Value
+			b.Client.LatenciesMs.P90 = p.Value * 1000
 		case 99:
-			b.Client.LatenciesMs.P99 = p.Value
+			b.Client.LatenciesMs.P99 = p.Value * 1000
+		}
+	}
+
+	if stagesI, ok := m.Result["stages"]; ok {
+		stages, _ := stagesI.([]interface{})
+		for _, stI := range stages {
+			st, _ := stI.(map[string]interface{})
+			stage := &LoadTestStage{}
+			stage.Name, _ = st["name"].(string)
+			stageType, _ := st["type"].(string)
+			stage.Type = LoadTestStageType(stageType)
+			dur, _ := st["duration"].(string)
+			stage.Duration, _ = time.ParseDuration(dur)
+			stage.QPS, _ = st["qps"].(float64)
+			b.Stages = append(b.Stages, stage)
+		}
+	}
+
+	if endpointsI, ok := m.Result["endpoints"]; ok {
+		bd, err := json.Marshal(endpointsI)
+		if err == nil {
+			err = json.Unmarshal(bd, &b.Endpoints)
+		}
+		if err != nil {
+			logrus.Warnf("unable to convert the scenario endpoints: %v", err)
 		}
 	}
 
@@ -155,12 +443,34 @@ func (m *MesheryResult) ConvertToSpec() (*BenchmarkSpec, error) {
 	if ok {
 		k8s, _ := k8sI.(map[string]interface{})
 		b.Env.Kubernetes, _ = k8s["server_version"].(string)
-		nodesI, okk := k8s["nodes"]
-		if okk {
-			nodes, okkk := nodesI.([]*K8SNode)
-			if okkk {
-				b.Env.NodeCount = len(nodes)
-			}
+		switch nodes := k8s["nodes"].(type) {
+		case []*K8SNode:
+			b.Env.NodeCount = len(nodes)
+		case []interface{}:
+			b.Env.NodeCount = len(nodes)
+		default:
+			// imported results only have the number of nodes
+			count, _ := k8s["node_count"].(float64)
+			b.Env.NodeCount = int(count)
+		}
+	}
+
+	b.MeshBuild, _ = m.Result["mesh_build"].(string)
+	b.ProxyBuild, _ = m.Result["proxy_build"].(string)
+	for key, field := range map[string]interface{}{
+		"mesh_config": &b.Config,
+		"metrics":     &b.Metrics,
+	} {
+		v, ok := m.Result[key]
+		if !ok {
+			continue
+		}
+		bd, err := json.Marshal(v)
+		if err == nil {
+			err = json.Unmarshal(bd, field)
+		}
+		if err != nil {
+			logrus.Warnf("unable to convert the %s of the result: %v", key, err)
 		}
 	}
 	return b, nil
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"fortio.org/fortio/periodic"
+)
+
+// LoadGeneratorFeature - represents an optional capability of a load generator
+type LoadGeneratorFeature string
+
+const (
+	// HTTPFeature - the generator can load HTTP services
+	HTTPFeature LoadGeneratorFeature = "http"
+
+	// GRPCFeature - the generator can load gRPC services
+	GRPCFeature LoadGeneratorFeature = "grpc"
+
+	// CustomRequestFeature - the generator can send custom methods, headers, bodies and credentials
+	CustomRequestFeature LoadGeneratorFeature = "custom_request"
+
+	// ConnectionOptionsFeature - the generator honours the request timeout, keep alive, HTTP/1.0 and compression options
+	ConnectionOptionsFeature LoadGeneratorFeature = "connection_options"
+
+	// ClientTLSFeature - the generator can be used with client certificates and custom CA certificates
+	ClientTLSFeature LoadGeneratorFeature = "client_tls"
+
+	// ScenarioFeature - the generator can be used for multi-endpoint scenarios
+	ScenarioFeature LoadGeneratorFeature = "scenarios"
+
+	// StagesFeature - the generator can be used for staged load profiles
+	StagesFeature LoadGeneratorFeature = "stages"
+
+	// OpenModelFeature - the generator sends requests following an arrival distribution, independently of the responses
+	OpenModelFeature LoadGeneratorFeature = "open_model"
+
+	// StopConditionsFeature - the generator can be used for load tests stopping early on errors or latency
+	StopConditionsFeature LoadGeneratorFeature = "stop_conditions"
+)
+
+// LoadGeneratorCapabilities - describes a load generator and the features it supports
+type LoadGeneratorCapabilities struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Features    []LoadGeneratorFeature `json:"features"`
+}
+
+// Supports - checks if the feature is supported
+func (c *LoadGeneratorCapabilities) Supports(feature LoadGeneratorFeature) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadGeneratorInterface defines the methods a load generator has to implement to be used for load tests
+type LoadGeneratorInterface interface {
+	Name() LoadGenerator
+	Capabilities() *LoadGeneratorCapabilities
+	// Validate - checks that the generator can run a load test with the given options
+	Validate(opts *LoadTestOptions) error
+	Run(ctx context.Context, opts *LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error)
+}
+
+// LoadGeneratorRegistryInterface defines the methods for registering and looking up load generators
+type LoadGeneratorRegistryInterface interface {
+	Register(gen LoadGeneratorInterface) error
+	Get(name LoadGenerator) (LoadGeneratorInterface, error)
+	List() []LoadGeneratorInterface
+}
+
+// RequiredFeatures - returns the load generator features needed to run a load test with the options
+func (o *LoadTestOptions) RequiredFeatures() []LoadGeneratorFeature {
+	features := []LoadGeneratorFeature{HTTPFeature}
+	if o.IsGRPC {
+		features = []LoadGeneratorFeature{GRPCFeature}
+	}
+	customRequest := len(o.Headers) > 0 || (o.Method != "" && !strings.EqualFold(o.Method, http.MethodGet)) ||
+		len(o.Payload) > 0 || o.ContentType != "" || o.UserCredentials != "" || o.BearerToken != ""
+	for _, ep := range o.Endpoints {
+		if len(ep.Headers) > 0 || (ep.Method != "" && !strings.EqualFold(ep.Method, http.MethodGet)) {
+			customRequest = true
+		}
+	}
+	if customRequest {
+		features = append(features, CustomRequestFeature)
+	}
+	if o.HTTPReqTimeout > 0 || o.DisableKeepAlive || o.HTTP10 || o.Compression {
+		features = append(features, ConnectionOptionsFeature)
+	}
+	if o.Cert != "" || o.Key != "" || o.CACert != "" {
+		features = append(features, ClientTLSFeature)
+	}
+	if len(o.Endpoints) > 0 {
+		features = append(features, ScenarioFeature)
+	}
+	if len(o.Stages) > 0 {
+		features = append(features, StagesFeature)
+	}
+	if o.Arrival == PoissonArrival {
+		features = append(features, OpenModelFeature)
+	}
+	if !o.StopConditions.IsEmpty() {
+		features = append(features, StopConditionsFeature)
+	}
+	return features
+}
+
+// ValidateLoadGeneratorFeatures - checks that the generator supports all the features needed for the options
+func ValidateLoadGeneratorFeatures(gen LoadGeneratorInterface, opts *LoadTestOptions) error {
+	caps := gen.Capabilities()
+	for _, f := range opts.RequiredFeatures() {
+		if !caps.Supports(f) {
+			return fmt.Errorf("%s does not support the %s feature", gen.Name(), f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// LoadTestJobStatus - used for representing the state of a load test job
+type LoadTestJobStatus string
+
+const (
+	// LoadTestJobQueued - represents a job which has been created but not started yet
+	LoadTestJobQueued LoadTestJobStatus = "queued"
+
+	// LoadTestJobRunning - represents a job which is currently running
+	LoadTestJobRunning LoadTestJobStatus = "running"
+
+	// LoadTestJobCompleted - represents a job which completed successfully
+	LoadTestJobCompleted LoadTestJobStatus = "completed"
+
+	// LoadTestJobFailed - represents a job which ended with an error
+	LoadTestJobFailed LoadTestJobStatus = "failed"
+
+	// LoadTestJobCancelled - represents a job which was cancelled midway
+	LoadTestJobCancelled LoadTestJobStatus = "cancelled"
+)
+
+// IsTerminal - returns true if a job in this status will not change anymore
+func (s LoadTestJobStatus) IsTerminal() bool {
+	return s == LoadTestJobCompleted || s == LoadTestJobFailed || s == LoadTestJobCancelled
+}
+
+// LoadTestJob - represents a load test run which lives independently of the client connection
+type LoadTestJob struct {
+	ID            uuid.UUID           `json:"id"`
+	Name          string              `json:"name,omitempty"`
+	Mesh          string              `json:"mesh,omitempty"`
+	TestUUID      string              `json:"test_uuid,omitempty"`
+	URL           string              `json:"url,omitempty"`
+	LoadGenerator string              `json:"load_generator,omitempty"`
+	UserID        string              `json:"user_id,omitempty"`
+	Provider      string              `json:"provider,omitempty"`
+	Status        LoadTestJobStatus   `json:"status"`
+	ResultID      string              `json:"result_id,omitempty"`
+	Messages      []*LoadTestResponse `json:"messages,omitempty"`
+	// Progress - latest live metrics of the test, kept out of the messages
+	Progress  *LoadTestSnapshot `json:"progress,omitempty"`
+	CreatedAt time.Time         `json:"created_at,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at,omitempty"`
+}
+
+// OwnedBy - tells whether the user of the provider who started the job can see and cancel it, the jobs created before
+// they had an owner are left to every user until they are pruned
+func (j *LoadTestJob) OwnedBy(userID, provider string) bool {
+	return j.UserID == "" || (j.UserID == userID && j.Provider == provider)
+}
+
+// LoadTestJobTrackerInterface defines the methods for tracking load test jobs
+type LoadTestJobTrackerInterface interface {
+	// AddJob registers a new job along with the function which cancels it
+	AddJob(ctx context.Context, job *LoadTestJob, cancel context.CancelFunc) error
+	GetJob(ctx context.Context, id uuid.UUID) (*LoadTestJob, error)
+	// GetJobs returns all the known jobs, filtered by status when status is not empty
+	GetJobs(ctx context.Context, status LoadTestJobStatus) ([]*LoadTestJob, error)
+	// PublishMessage records the message on the job and forwards it to all subscribers,
+	// only the latest progress message is kept
+	PublishMessage(ctx context.Context, id uuid.UUID, msg *LoadTestResponse)
+	// UpdateJobStatus updates the job status, a terminal status closes all subscriptions
+	UpdateJobStatus(ctx context.Context, id uuid.UUID, status LoadTestJobStatus, resultID string)
+	// Subscribe returns the messages published so far, a channel for the upcoming ones and a func to unsubscribe
+	Subscribe(ctx context.Context, id uuid.UUID) ([]*LoadTestResponse, <-chan *LoadTestResponse, func(), error)
+	CancelJob(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+// LoadTestProfile - represents a named load test saved by a user so it can be run again by its id
+type LoadTestProfile struct {
+	ID                 uuid.UUID           `json:"id"`
+	Name               string              `json:"name"`
+	Mesh               string              `json:"mesh,omitempty"`
+	URL                string              `json:"url"`
+	LoadGenerator      string              `json:"gen,omitempty"`
+	ConcurrentRequests int                 `json:"c,omitempty"`
+	QueriesPerSecond   float64             `json:"qps,omitempty"`
+	Duration           string              `json:"t,omitempty"`
+	Headers            map[string]string   `json:"headers,omitempty"`
+	Thresholds         *LoadTestThresholds `json:"thresholds,omitempty"`
+	CreatedAt          time.Time           `json:"created_at,omitempty"`
+	UpdatedAt          time.Time           `json:"updated_at,omitempty"`
+}
+
+// Validate - validates the profile, the load generator is checked against the registered ones by the caller
+func (p *LoadTestProfile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("please provide a name for the profile")
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil || !u.IsAbs() {
+		return errors.New("invalid load test URL")
+	}
+	if p.ConcurrentRequests < 0 {
+		return fmt.Errorf("invalid concurrent requests: %d", p.ConcurrentRequests)
+	}
+	if p.QueriesPerSecond < 0 {
+		return fmt.Errorf("invalid qps: %g", p.QueriesPerSecond)
+	}
+	if p.Duration != "" {
+		d, err := time.ParseDuration(p.Duration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration: %s", p.Duration)
+		}
+		if d%time.Millisecond != 0 {
+			return fmt.Errorf("the duration must be a whole number of milliseconds: %s", p.Duration)
+		}
+	}
+	for k := range p.Headers {
+		if strings.TrimSpace(k) == "" {
+			return errors.New("invalid header with an empty name")
+		}
+	}
+	if !p.Thresholds.IsEmpty() {
+		return p.Thresholds.Validate()
+	}
+	return nil
+}
+
+// GetLoadTestProfile - returns the saved profile with the given id, nil when there is none
+func (p *Preference) GetLoadTestProfile(id uuid.UUID) *LoadTestProfile {
+	for _, profile := range p.LoadTestProfiles {
+		if profile.ID == id {
+			return profile
+		}
+	}
+	return nil
+}
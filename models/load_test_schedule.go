@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// MaxLoadTestScheduleRuns - number of runs kept in the history of a schedule
+const MaxLoadTestScheduleRuns = 100
+
+// LoadTestDefinition - represents a load test as it is submitted to the load test APIs, so it can be run again later
+type LoadTestDefinition struct {
+	// SMPS - whether the body is a benchmark spec for the SMPS API, else the test is submitted to the load test API
+	SMPS        bool   `json:"smps,omitempty"`
+	Query       string `json:"query,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body,omitempty"`
+}
+
+// LoadTestScheduleRun - represents a run of a scheduled load test
+type LoadTestScheduleRun struct {
+	JobID    uuid.UUID         `json:"job_id,omitempty"`
+	ResultID string            `json:"result_id,omitempty"`
+	Status   LoadTestJobStatus `json:"status,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	// Skipped - the run was due while the previous one was still going
+	Skipped    bool       `json:"skipped,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// LoadTestSchedule - represents a load test run periodically as per a cron expression
+type LoadTestSchedule struct {
+	ID      uuid.UUID           `json:"id"`
+	Name    string              `json:"name,omitempty"`
+	Cron    string              `json:"cron"`
+	Test    *LoadTestDefinition `json:"test"`
+	Paused  bool                `json:"paused"`
+	NextRun *time.Time          `json:"next_run,omitempty"`
+	// UserID, Provider - owner of the schedule, the runs are attributed to the owner and use its preferences
+	UserID    string                 `json:"user_id,omitempty"`
+	Provider  string                 `json:"provider,omitempty"`
+	Runs      []*LoadTestScheduleRun `json:"runs,omitempty"`
+	CreatedAt time.Time              `json:"created_at,omitempty"`
+	UpdatedAt time.Time              `json:"updated_at,omitempty"`
+}
+
+// OwnedBy - tells whether the user of the provider can see and change the schedule, the schedules created before
+// they had an owner are left to every user to delete them
+func (s *LoadTestSchedule) OwnedBy(userID, provider string) bool {
+	return s.UserID == "" || (s.UserID == userID && s.Provider == provider)
+}
+
+// LoadTestLaunchFunc - launches the load test of a schedule and returns the id of its job
+type LoadTestLaunchFunc func(ctx context.Context, schedule *LoadTestSchedule) (uuid.UUID, error)
+
+// LoadTestSchedulerInterface defines the methods for managing scheduled load tests
+type LoadTestSchedulerInterface interface {
+	AddSchedule(ctx context.Context, schedule *LoadTestSchedule) error
+	GetSchedule(ctx context.Context, id uuid.UUID) (*LoadTestSchedule, error)
+	GetSchedules(ctx context.Context) ([]*LoadTestSchedule, error)
+	// PauseSchedule pauses or resumes the schedule
+	PauseSchedule(ctx context.Context, id uuid.UUID, paused bool) error
+	DeleteSchedule(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultStopWindow - window over which the error rate and the latency of a load test are checked
+	DefaultStopWindow = 30 * time.Second
+
+	// DefaultStopCheckInterval - how often the stop conditions of a load test are checked
+	DefaultStopCheckInterval = 5 * time.Second
+
+	// DefaultStopLatencyPercentile - percentile of the latency compared to MaxLatencyMs
+	DefaultStopLatencyPercentile = 90.0
+)
+
+// LoadTestStopConditions - represents when a load test is stopped before its end, the zero values are not checked.
+// The conditions are checked every CheckInterval over the requests of the last Window.
+type LoadTestStopConditions struct {
+	// StatusCodes - the test stops once a response has one of these codes, -1 stands for socket errors
+	StatusCodes       []int         `json:"status_codes,omitempty" yaml:"status_codes,omitempty"`
+	MaxErrorPercent   float64       `json:"max_error_percent,omitempty" yaml:"max_error_percent,omitempty"`
+	MaxLatencyMs      float64       `json:"max_latency_ms,omitempty" yaml:"max_latency_ms,omitempty"`
+	LatencyPercentile float64       `json:"latency_percentile,omitempty" yaml:"latency_percentile,omitempty"`
+	Window            time.Duration `json:"window,omitempty" yaml:"window,omitempty"`
+	CheckInterval     time.Duration `json:"check_interval,omitempty" yaml:"check_interval,omitempty"`
+}
+
+// IsEmpty - whether no condition is set
+func (c *LoadTestStopConditions) IsEmpty() bool {
+	return c == nil || (len(c.StatusCodes) == 0 && c.MaxErrorPercent == 0 && c.MaxLatencyMs == 0)
+}
+
+// Validate - validates the stop conditions and fills in the defaults
+func (c *LoadTestStopConditions) Validate() error {
+	for _, code := range c.StatusCodes {
+		if code != -1 && (code < 100 || code > 599) {
+			return fmt.Errorf("invalid status code to stop on: %d", code)
+		}
+	}
+	if c.MaxErrorPercent < 0 || c.MaxErrorPercent > 100 {
+		return fmt.Errorf("invalid error percentage to stop on: %g", c.MaxErrorPercent)
+	}
+	if c.MaxLatencyMs < 0 {
+		return fmt.Errorf("invalid latency to stop on: %g", c.MaxLatencyMs)
+	}
+	if c.LatencyPercentile == 0 {
+		c.LatencyPercentile = DefaultStopLatencyPercentile
+	}
+	if c.LatencyPercentile < 0 || c.LatencyPercentile > 100 {
+		return fmt.Errorf("invalid latency percentile: %g", c.LatencyPercentile)
+	}
+	if c.CheckInterval == 0 {
+		c.CheckInterval = DefaultStopCheckInterval
+	}
+	if c.CheckInterval < time.Second {
+		return errors.New("the stop conditions can not be checked more often than every second")
+	}
+	if c.Window == 0 {
+		c.Window = DefaultStopWindow
+	}
+	if c.Window < c.CheckInterval {
+		return fmt.Errorf("the stop window %v is shorter than the check interval %v", c.Window, c.CheckInterval)
+	}
+	return nil
+}
+
+// StopsOn - whether the test stops when a response has the given status code
+func (c *LoadTestStopConditions) StopsOn(code int) bool {
+	if c == nil {
+		return false
+	}
+	for _, sc := range c.StatusCodes {
+		if sc == code {
+			return true
+		}
+	}
+	return false
+}
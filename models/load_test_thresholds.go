@@ -0,0 +1,161 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fortio.org/fortio/stats"
+	"github.com/pkg/errors"
+)
+
+// LoadTestThresholds - represents the service level objectives a load test is checked against,
+// the zero values are not checked
+type LoadTestThresholds struct {
+	P50Ms           float64 `json:"p50_ms,omitempty" yaml:"p50_ms,omitempty"`
+	P90Ms           float64 `json:"p90_ms,omitempty" yaml:"p90_ms,omitempty"`
+	P99Ms           float64 `json:"p99_ms,omitempty" yaml:"p99_ms,omitempty"`
+	MaxErrorPercent float64 `json:"max_error_percent,omitempty" yaml:"max_error_percent,omitempty"`
+	MinQPS          float64 `json:"min_qps,omitempty" yaml:"min_qps,omitempty"`
+}
+
+// IsEmpty - whether no threshold is set
+func (t *LoadTestThresholds) IsEmpty() bool {
+	return t == nil || *t == LoadTestThresholds{}
+}
+
+// Validate - validates the thresholds
+func (t *LoadTestThresholds) Validate() error {
+	if t.P50Ms < 0 || t.P90Ms < 0 || t.P99Ms < 0 {
+		return errors.New("latency thresholds must not be negative")
+	}
+	if t.MaxErrorPercent < 0 || t.MaxErrorPercent > 100 {
+		return fmt.Errorf("invalid error percentage threshold: %g", t.MaxErrorPercent)
+	}
+	if t.MinQPS < 0 {
+		return fmt.Errorf("invalid qps threshold: %g", t.MinQPS)
+	}
+	return nil
+}
+
+// LoadTestCheck - represents the outcome of checking one threshold
+type LoadTestCheck struct {
+	Name      string  `json:"name"`
+	Threshold float64 `json:"threshold"`
+	Actual    float64 `json:"actual"`
+	Passed    bool    `json:"passed"`
+}
+
+// LoadTestVerdict - represents whether a load test met its thresholds
+type LoadTestVerdict struct {
+	Passed bool             `json:"passed"`
+	Checks []*LoadTestCheck `json:"checks"`
+}
+
+// String - summarises the verdict in a line per failed check
+func (v *LoadTestVerdict) String() string {
+	if v.Passed {
+		return fmt.Sprintf("passed all %d thresholds", len(v.Checks))
+	}
+	var failed []string
+	for _, c := range v.Checks {
+		if !c.Passed {
+			failed = append(failed, fmt.Sprintf("%s: %g (threshold %g)", c.Name, c.Actual, c.Threshold))
+		}
+	}
+	return "failed " + strings.Join(failed, ", ")
+}
+
+// Evaluate - checks the results of a load test against the thresholds, latencies are compared in milliseconds
+// and a request is counted as an error when it did not get a 2xx or 3xx HTTP code or a SERVING gRPC status
+func (t *LoadTestThresholds) Evaluate(resultsMap map[string]interface{}) (*LoadTestVerdict, error) {
+	bd, err := json.Marshal(resultsMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the load test results")
+	}
+	res := struct {
+		RunType           string
+		ActualQPS         float64
+		DurationHistogram *stats.HistogramData
+		RetCodes          map[string]int64
+	}{}
+	if err = json.Unmarshal(bd, &res); err != nil {
+		return nil, errors.Wrap(err, "unable to read the load test results")
+	}
+	if res.DurationHistogram == nil {
+		return nil, errors.New("the load test results have no latency histogram")
+	}
+
+	verdict := &LoadTestVerdict{Passed: true, Checks: []*LoadTestCheck{}}
+	// check records a threshold which must not be exceeded, or reached when min is set
+	check := func(name string, threshold, actual float64, min bool) {
+		c := &LoadTestCheck{
+			Name:      name,
+			Threshold: threshold,
+			Actual:    actual,
+			Passed:    actual <= threshold,
+		}
+		if min {
+			c.Passed = actual >= threshold
+		}
+		verdict.Passed = verdict.Passed && c.Passed
+		verdict.Checks = append(verdict.Checks, c)
+	}
+	for _, p := range []struct {
+		name       string
+		percentile float64
+		threshold  float64
+	}{
+		{"p50_ms", 50, t.P50Ms},
+		{"p90_ms", 90, t.P90Ms},
+		{"p99_ms", 99, t.P99Ms},
+	} {
+		if p.threshold <= 0 {
+			continue
+		}
+		check(p.name, p.threshold, PercentileMs(res.DurationHistogram, p.percentile), false)
+	}
+	if t.MaxErrorPercent > 0 {
+		var total, failed int64
+		for code, count := range res.RetCodes {
+			total += count
+			if !IsSuccessCode(res.RunType, code) {
+				failed += count
+			}
+		}
+		var actual float64
+		if total > 0 {
+			actual = float64(failed) * 100 / float64(total)
+		}
+		check("error_percent", t.MaxErrorPercent, actual, false)
+	}
+	if t.MinQPS > 0 {
+		check("qps", t.MinQPS, res.ActualQPS, true)
+	}
+	return verdict, nil
+}
+
+// PercentileMs - returns a percentile of the histogram in milliseconds, the exported percentiles are preferred
+// to the buckets as imported results only approximate them
+func PercentileMs(h *stats.HistogramData, p float64) float64 {
+	for _, pp := range h.Percentiles {
+		if pp.Percentile == p {
+			return pp.Value * 1000
+		}
+	}
+	if h.Count == 0 || len(h.Data) == 0 {
+		return 0
+	}
+	return h.CalcPercentile(p) * 1000
+}
+
+// IsSuccessCode - whether a code of the results of the given run type is a success, gRPC results report
+// the serving status instead of HTTP codes
+func IsSuccessCode(runType, code string) bool {
+	if strings.HasPrefix(runType, "GRPC") {
+		return code == "SERVING"
+	}
+	c, err := strconv.Atoi(code)
+	return err == nil && c >= 200 && c < 400
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"fortio.org/fortio/periodic"
+)
+
+// WorkerTokenHeader - header carrying the token shared by the coordinator and its workers
+const WorkerTokenHeader = "X-Meshery-Worker-Token"
+
+// WorkerLoadTestRequest - represents the share of a distributed load test sent to a worker
+type WorkerLoadTestRequest struct {
+	Options *LoadTestOptions `json:"options,omitempty"`
+	// StartAt - the time all the workers start generating load at
+	StartAt time.Time `json:"start_at,omitempty"`
+}
+
+// WorkerLoadTestResponse - represents the outcome of a worker's share of a distributed load test
+type WorkerLoadTestResponse struct {
+	Result map[string]interface{} `json:"result,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// LoadTestCoordinatorInterface defines the methods for running load tests on remote workers
+type LoadTestCoordinatorInterface interface {
+	Workers() []string
+	Run(ctx context.Context, opts *LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error)
+}
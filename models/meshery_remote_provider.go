@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -305,6 +306,11 @@ func (l *MesheryRemoteProvider) FetchResults(req *http.Request, page, pageSize,
 	if order != "" {
 		q.Set("order", order)
 	}
+	for _, param := range []string{"mesh", "url", "tag", "from", "to", "cursor"} {
+		if v, ok := req.URL.Query()[param]; ok {
+			q[param] = v
+		}
+	}
 	saasURL.RawQuery = q.Encode()
 	logrus.Debugf("constructed results url: %s", saasURL.String())
 	cReq, _ := http.NewRequest(http.MethodGet, saasURL.String(), nil)
@@ -384,6 +390,102 @@ func (l *MesheryRemoteProvider) GetResult(req *http.Request, resultID uuid.UUID)
 	return nil, fmt.Errorf("error while getting result - Status code: %d, Body: %s", resp.StatusCode, bdr)
 }
 
+// DeleteResult - deletes the result with the given id from the provider backend
+func (l *MesheryRemoteProvider) DeleteResult(req *http.Request, resultID uuid.UUID) error {
+	logrus.Infof("attempting to delete result from cloud for id: %s", resultID)
+	bdr, status, err := l.doResultRequest(req, http.MethodDelete, resultID, nil)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusOK, http.StatusNoContent:
+		logrus.Infof("result successfully deleted from SaaS")
+		return nil
+	case http.StatusNotFound:
+		return ErrResultNotFound
+	}
+	logrus.Errorf("error while deleting result: %s", bdr)
+	return fmt.Errorf("error while deleting result - Status code: %d, Body: %s", status, bdr)
+}
+
+// UpdateResult - renames, tags or annotates the result with the given id in the provider backend
+func (l *MesheryRemoteProvider) UpdateResult(req *http.Request, resultID uuid.UUID, update *ResultUpdate) (*MesheryResult, error) {
+	logrus.Infof("attempting to update result in cloud for id: %s", resultID)
+	data, err := json.Marshal(update)
+	if err != nil {
+		return nil, errors.Wrap(err, "error - unable to marshal the result update")
+	}
+	bdr, status, err := l.doResultRequest(req, http.MethodPatch, resultID, data)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		logrus.Infof("result successfully updated in SaaS")
+		res := &MesheryResult{}
+		if err = json.Unmarshal(bdr, res); err != nil {
+			logrus.Errorf("unable to unmarshal meshery result: %v", err)
+			return nil, err
+		}
+		return res, nil
+	case http.StatusNotFound:
+		return nil, ErrResultNotFound
+	}
+	logrus.Errorf("error while updating result: %s", bdr)
+	return nil, fmt.Errorf("error while updating result - Status code: %d, Body: %s", status, bdr)
+}
+
+// ExportResults - the results of the provider backend are not exported
+func (l *MesheryRemoteProvider) ExportResults(req *http.Request, w io.Writer) (*ResultsArchiveManifest, error) {
+	return nil, ErrResultsArchiveNotSupported
+}
+
+// ImportResults - the results are not imported into the provider backend
+func (l *MesheryRemoteProvider) ImportResults(req *http.Request, r io.Reader) (*ResultsImportSummary, error) {
+	return nil, ErrResultsArchiveNotSupported
+}
+
+// ImportResult - the results of the provider are kept by its backend, so an imported result is published there
+func (l *MesheryRemoteProvider) ImportResult(req *http.Request, result *MesheryResult) (string, error) {
+	return l.PublishResults(req, result)
+}
+
+// doResultRequest sends a request about a result to SaaS and returns the body and the status of the response
+func (l *MesheryRemoteProvider) doResultRequest(req *http.Request, method string, resultID uuid.UUID, data []byte) ([]byte, int, error) {
+	session, _ := l.GetSession(req)
+
+	tokenVal, _ := session.Values[l.SaaSTokenName].(string)
+
+	saasURL, _ := url.Parse(fmt.Sprintf("%s/result/%s", l.SaaSBaseURL, resultID.String()))
+	logrus.Debugf("constructed result url: %s", saasURL.String())
+	cReq, _ := http.NewRequest(method, saasURL.String(), bytes.NewReader(data))
+	if data != nil {
+		cReq.Header.Set("content-type", "application/json")
+	}
+	cReq.AddCookie(&http.Cookie{
+		Name:     l.SaaSTokenName,
+		Value:    tokenVal,
+		Path:     "/",
+		HttpOnly: true,
+		Domain:   saasURL.Hostname(),
+	})
+	c := &http.Client{}
+	resp, err := c.Do(cReq)
+	if err != nil {
+		logrus.Errorf("unable to send the result request: %v", err)
+		return nil, 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	bdr, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		logrus.Errorf("unable to read response body: %v", err)
+		return nil, 0, err
+	}
+	return bdr, resp.StatusCode, nil
+}
+
 // PublishResults - publishes results to the provider backend syncronously
 func (l *MesheryRemoteProvider) PublishResults(req *http.Request, result *MesheryResult) (string, error) {
 	data, err := json.Marshal(result)
@@ -1,10 +1,13 @@
 package models
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/gob"
 	"time"
 
 	"github.com/grafana-tools/sdk"
+	"github.com/pkg/errors"
 )
 
 // K8SConfig represents all the k8s session config
@@ -74,6 +77,40 @@ type LoadTestPreferences struct {
 	LoadGenerator      string `json:"gen,omitempty"`
 }
 
+// LoadTestCerts represents the PEM encoded TLS material used by the load generators,
+// the client certificate and key are presented to services requiring mutual TLS
+type LoadTestCerts struct {
+	Cert string `json:"cert,omitempty"`
+	// Key - never marshaled, so that it does not leave the server with the preferences synced to the provider,
+	// the preference persisters keep it on their own
+	Key       string    `json:"-"`
+	CACert    string    `json:"caCert,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate - checks that the certificate matches its key and that the CA certificates can be parsed
+func (c *LoadTestCerts) Validate() error {
+	if c.Cert == "" && c.Key == "" && c.CACert == "" {
+		return errors.New("no certificates given")
+	}
+	if c.Cert != "" || c.Key != "" {
+		if _, err := tls.X509KeyPair([]byte(c.Cert), []byte(c.Key)); err != nil {
+			return errors.Wrap(err, "invalid client certificate or key")
+		}
+	}
+	if c.CACert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(c.CACert)) {
+		return errors.New("invalid CA certificate")
+	}
+	return nil
+}
+
+// Redacted - returns a copy of the certificates without the private key, fit for display
+func (c *LoadTestCerts) Redacted() *LoadTestCerts {
+	rc := *c
+	rc.Key = ""
+	return &rc
+}
+
 // Preference represents the data stored in session / local DB
 type Preference struct {
 	K8SConfig            *K8SConfig           `json:"k8sConfig,omitempty"`
@@ -81,6 +118,8 @@ type Preference struct {
 	Grafana              *Grafana             `json:"grafana,omitempty"`
 	Prometheus           *Prometheus          `json:"prometheus,omitempty"`
 	LoadTestPreferences  *LoadTestPreferences `json:"loadTestPrefs,omitempty"`
+	LoadTestCerts        *LoadTestCerts       `json:"loadTestCerts,omitempty"`
+	LoadTestProfiles     []*LoadTestProfile   `json:"loadTestProfiles,omitempty"`
 	AnonymousUsageStats  bool                 `json:"anonymousUsageStats"`
 	AnonymousPerfResults bool                 `json:"anonymousPerfResults"`
 	UpdatedAt            time.Time            `json:"updated_at,omitempty"`
@@ -12,6 +12,10 @@ import (
 	"time"
 )
 
+// loadTestKeyPrefix is the prefix of the keys of the private keys of the load test certificates of the users,
+// which are stored apart from the preferences as they are not marshaled with them
+const loadTestKeyPrefix = "load_test_key:"
+
 // BitCaskPreferencePersister assists with persisting session in a Bitcask store
 type BitCaskPreferencePersister struct {
 	fileName string
@@ -98,6 +102,20 @@ RETRY:
 			return nil, err
 		}
 	}
+	if data.LoadTestCerts != nil {
+		if key, err := s.db.Get([]byte(loadTestKeyPrefix + userID)); err == nil {
+			data.LoadTestCerts.Key = string(key)
+		} else {
+			// the preferences saved before the key was stored apart have it inline
+			legacy := struct {
+				LoadTestCerts struct {
+					Key string `json:"key"`
+				} `json:"loadTestCerts"`
+			}{}
+			_ = json.Unmarshal(dataCopyB, &legacy)
+			data.LoadTestCerts.Key = legacy.LoadTestCerts.Key
+		}
+	}
 
 	_ = s.writeToCache(userID, data)
 	return data, nil
@@ -161,6 +179,16 @@ RETRY:
 		err = errors.Wrapf(err, "Unable to persist config data.")
 		return err
 	}
+	keyID := []byte(loadTestKeyPrefix + userID)
+	if data.LoadTestCerts != nil && data.LoadTestCerts.Key != "" {
+		if err := s.db.Put(keyID, []byte(data.LoadTestCerts.Key)); err != nil {
+			return errors.Wrapf(err, "Unable to persist the load test key.")
+		}
+	} else if s.db.Has(keyID) {
+		if err := s.db.Delete(keyID); err != nil {
+			return errors.Wrapf(err, "Unable to delete the load test key.")
+		}
+	}
 	return nil
 }
 
@@ -192,6 +220,12 @@ RETRY:
 		err = errors.Wrapf(err, "Unable to delete config data for the user: %s.", userID)
 		return err
 	}
+	if keyID := []byte(loadTestKeyPrefix + userID); s.db.Has(keyID) {
+		if err := s.db.Delete(keyID); err != nil {
+			err = errors.Wrapf(err, "Unable to delete the load test key of the user: %s.", userID)
+			return err
+		}
+	}
 	return nil
 }
 
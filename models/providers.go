@@ -1,6 +1,7 @@
 package models
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/gofrs/uuid"
@@ -55,5 +56,10 @@ type Provider interface {
 	PublishResults(req *http.Request, result *MesheryResult) (string, error)
 	PublishMetrics(tokenVal string, data *MesheryResult) error
 	GetResult(*http.Request, uuid.UUID) (*MesheryResult, error)
+	DeleteResult(*http.Request, uuid.UUID) error
+	UpdateResult(*http.Request, uuid.UUID, *ResultUpdate) (*MesheryResult, error)
+	ExportResults(*http.Request, io.Writer) (*ResultsArchiveManifest, error)
+	ImportResults(*http.Request, io.Reader) (*ResultsImportSummary, error)
+	ImportResult(*http.Request, *MesheryResult) (string, error)
 	RecordPreferences(req *http.Request, userID string, data *Preference) error
 }
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// DefaultRegressionTolerance - change in percent below which a difference is never reported as a regression
+const DefaultRegressionTolerance = 10.0
+
+// ResultSummary - represents the key figures of a result, or of several results pooled together
+type ResultSummary struct {
+	ID           uuid.UUID        `json:"meshery_id,omitempty"`
+	Name         string           `json:"name,omitempty"`
+	Mesh         string           `json:"mesh,omitempty"`
+	MeshBuild    string           `json:"mesh_build,omitempty"`
+	ProxyBuild   string           `json:"proxy_build,omitempty"`
+	StartTime    time.Time        `json:"start_time,omitempty"`
+	Runs         int              `json:"runs"`
+	Count        int64            `json:"count"`
+	QPS          float64          `json:"qps"`
+	AvgMs        float64          `json:"avg_ms"`
+	P50Ms        float64          `json:"p50_ms"`
+	P90Ms        float64          `json:"p90_ms"`
+	P99Ms        float64          `json:"p99_ms"`
+	ErrorPercent float64          `json:"error_percent"`
+	RetCodes     map[string]int64 `json:"ret_codes,omitempty"`
+}
+
+// MetricDiff - represents the change of a metric between the baseline and a candidate
+type MetricDiff struct {
+	Metric       string  `json:"metric"`
+	Baseline     float64 `json:"baseline"`
+	Candidate    float64 `json:"candidate"`
+	Delta        float64 `json:"delta"`
+	DeltaPercent float64 `json:"delta_percent"`
+	Regression   bool    `json:"regression"`
+}
+
+// RetCodeDiff - represents the change of the count of a return code between the baseline and a candidate
+type RetCodeDiff struct {
+	Code      string `json:"code"`
+	Baseline  int64  `json:"baseline"`
+	Candidate int64  `json:"candidate"`
+}
+
+// ResultDiff - represents how a candidate result compares to the baseline
+type ResultDiff struct {
+	Candidate *ResultSummary `json:"candidate"`
+	Metrics   []*MetricDiff  `json:"metrics"`
+	RetCodes  []*RetCodeDiff `json:"ret_codes"`
+	// ServerMetrics - change of the mean of each Prometheus series recorded with both results
+	ServerMetrics []*MetricDiff `json:"server_metrics,omitempty"`
+	// LatencyPValue - one sided Mann-Whitney U test of the candidate latencies being higher,
+	// absent when the histograms of the results have no buckets
+	LatencyPValue *float64 `json:"latency_p_value,omitempty"`
+	Regression    bool     `json:"regression"`
+	Reasons       []string `json:"reasons,omitempty"`
+}
+
+// ResultComparison - represents the comparison of candidate results against a baseline
+type ResultComparison struct {
+	// Baseline - the baseline results pooled together
+	Baseline     *ResultSummary   `json:"baseline"`
+	BaselineRuns []*ResultSummary `json:"baseline_runs"`
+	Comparisons  []*ResultDiff    `json:"comparisons"`
+	// Tolerance - change in percent below which a difference is not a regression
+	Tolerance float64 `json:"tolerance"`
+	// Confidence - confidence level of the statistical tests
+	Confidence float64 `json:"confidence"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrResultNotFound is returned when there is no result with the given id
+var ErrResultNotFound = errors.New("result not found")
+
+const (
+	maxResultTags      = 32
+	maxResultTagLength = 64
+)
+
+// ResultUpdate - represents a change of the name, the tags or the notes of a result, the fields which are nil are left as they are
+type ResultUpdate struct {
+	Name  *string   `json:"name,omitempty"`
+	Tags  *[]string `json:"tags,omitempty"`
+	Notes *string   `json:"notes,omitempty"`
+}
+
+// Validate - checks the update and normalizes it, the tags being trimmed and deduplicated
+func (u *ResultUpdate) Validate() error {
+	if u.Name == nil && u.Tags == nil && u.Notes == nil {
+		return errors.New("nothing to update, expecting a name, tags or notes")
+	}
+	if u.Name != nil {
+		name := strings.TrimSpace(*u.Name)
+		if name == "" {
+			return errors.New("the name of a result can not be empty")
+		}
+		u.Name = &name
+	}
+	if u.Tags != nil {
+		tags, err := normalizeResultTags(*u.Tags)
+		if err != nil {
+			return err
+		}
+		u.Tags = &tags
+	}
+	return nil
+}
+
+// Apply - applies the update to the result
+func (u *ResultUpdate) Apply(result *MesheryResult) {
+	if u.Name != nil {
+		result.Name = *u.Name
+	}
+	if u.Tags != nil {
+		result.Tags = *u.Tags
+	}
+	if u.Notes != nil {
+		result.Notes = *u.Notes
+	}
+}
+
+func normalizeResultTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, errors.New("tags can not be empty")
+		}
+		if len(t) > maxResultTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", t, maxResultTagLength)
+		}
+		if seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		normalized = append(normalized, t)
+	}
+	if len(normalized) > maxResultTags {
+		return nil, fmt.Errorf("a result can not have more than %d tags", maxResultTags)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,270 @@
+package models
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ResultsArchiveKind - kind of the manifest of a results archive
+	ResultsArchiveKind = "meshery-results"
+	// ResultsArchiveSchemaVersion - version of the layout of the results archives, the archives of a newer
+	// version are not imported
+	ResultsArchiveSchemaVersion = 1
+
+	resultsArchiveManifestName = "manifest.json"
+	resultsArchiveResultsName  = "results.jsonl"
+)
+
+// ErrResultsArchiveNotSupported is returned by the providers which do not store the results locally
+var ErrResultsArchiveNotSupported = errors.New("exporting and importing results is only supported for the results stored locally")
+
+// ResultsArchiveManifest - describes a results archive, a gzipped tarball whose first file is the manifest
+// followed by the results as JSON lines
+type ResultsArchiveManifest struct {
+	Kind          string    `json:"kind"`
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Results       int       `json:"results"`
+}
+
+// ResultsArchiveRecord - represents a line of the results of an archive, the result has its server metrics
+// and is stored under the ID of the record
+type ResultsArchiveRecord struct {
+	ID        uuid.UUID       `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Result    json.RawMessage `json:"result"`
+}
+
+// ResultsImportSummary - represents the outcome of the import of a results archive, the results which are
+// already stored being skipped
+type ResultsImportSummary struct {
+	Manifest *ResultsArchiveManifest `json:"manifest"`
+	Imported int                     `json:"imported"`
+	Skipped  int                     `json:"skipped"`
+	Invalid  int                     `json:"invalid"`
+}
+
+// ExportResults - writes an archive of all the results, from the oldest one
+func (s *BitCaskResultsPersister) ExportResults(w io.Writer) (*ResultsArchiveManifest, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+	// the results are written to a temporary file first as the size of a file comes first in a tarball
+	tmp, err := ioutil.TempFile("", "meshery-results-")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create a temporary file for the export")
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	manifest := &ResultsArchiveManifest{
+		Kind:          ResultsArchiveKind,
+		SchemaVersion: ResultsArchiveSchemaVersion,
+		CreatedAt:     time.Now(),
+	}
+	if manifest.Results, err = s.writeResultsRecords(tmp); err != nil {
+		return nil, err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to export the results")
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "unable to export the results")
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the manifest of the export")
+	}
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := writeTarFile(tw, resultsArchiveManifestName, manifest.CreatedAt, int64(len(data)), bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := writeTarFile(tw, resultsArchiveResultsName, manifest.CreatedAt, size, tmp); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrap(err, "unable to write the archive")
+	}
+	if err := gw.Close(); err != nil {
+		return nil, errors.Wrap(err, "unable to write the archive")
+	}
+	return manifest, nil
+}
+
+// writeResultsRecords writes the results as JSON lines and returns how many were written
+func (s *BitCaskResultsPersister) writeResultsRecords(w io.Writer) (int, error) {
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
+
+	s.index.RLock()
+	entries := make([]*resultIndexEntry, len(s.index.ordered))
+	copy(entries, s.index.ordered)
+	s.index.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	count := 0
+	// the oldest results come first, so that an import writes them in the order they were created
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		data, err := s.db.Get(e.ID.Bytes())
+		if err != nil {
+			// deleted in the meantime
+			continue
+		}
+		if err := enc.Encode(&ResultsArchiveRecord{ID: e.ID, CreatedAt: e.CreatedAt, Result: data}); err != nil {
+			return 0, errors.Wrapf(err, "unable to export result %s", e.ID)
+		}
+		count++
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, errors.Wrap(err, "unable to export the results")
+	}
+	return count, nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, modTime time.Time, size int64, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: modTime,
+	}); err != nil {
+		return errors.Wrapf(err, "unable to write %s to the archive", name)
+	}
+	if _, err := io.CopyN(tw, r, size); err != nil {
+		return errors.Wrapf(err, "unable to write %s to the archive", name)
+	}
+	return nil
+}
+
+// ImportResults - imports the results of an archive, the results whose id is already stored are skipped
+// and the lines which are not results are counted as invalid
+func (s *BitCaskResultsPersister) ImportResults(r io.Reader) (*ResultsImportSummary, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "the archive is not a gzipped tarball")
+	}
+	defer func() {
+		_ = gr.Close()
+	}()
+	tr := tar.NewReader(gr)
+
+	summary := &ResultsImportSummary{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read the archive")
+		}
+		switch hdr.Name {
+		case resultsArchiveManifestName:
+			m := &ResultsArchiveManifest{}
+			if err := json.NewDecoder(tr).Decode(m); err != nil {
+				return nil, errors.Wrap(err, "unable to parse the manifest of the archive")
+			}
+			if m.Kind != ResultsArchiveKind {
+				return nil, fmt.Errorf("the archive is not an archive of Meshery results: kind %q", m.Kind)
+			}
+			if m.SchemaVersion < 1 || m.SchemaVersion > ResultsArchiveSchemaVersion {
+				return nil, fmt.Errorf("unsupported schema version %d of the archive, expecting at most %d", m.SchemaVersion, ResultsArchiveSchemaVersion)
+			}
+			summary.Manifest = m
+		case resultsArchiveResultsName:
+			if summary.Manifest == nil {
+				return nil, fmt.Errorf("the manifest must come first in the archive")
+			}
+			if err := s.importResultsRecords(tr, summary); err != nil {
+				return summary, err
+			}
+		default:
+			// the files of later versions which do not change the results
+			logrus.Debugf("ignoring %s in the results archive", hdr.Name)
+		}
+	}
+	if summary.Manifest == nil {
+		return nil, fmt.Errorf("the archive does not have a manifest")
+	}
+	return summary, nil
+}
+
+func (s *BitCaskResultsPersister) importResultsRecords(r io.Reader, summary *ResultsImportSummary) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return errors.Wrap(err, "unable to read the results of the archive")
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			if ierr := s.importResultsRecord(line, summary); ierr != nil {
+				logrus.Warnf("skipping line %d of the results of the archive: %v", n, ierr)
+				summary.Invalid++
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func (s *BitCaskResultsPersister) importResultsRecord(line []byte, summary *ResultsImportSummary) error {
+	rec := &ResultsArchiveRecord{}
+	if err := json.Unmarshal(line, rec); err != nil {
+		return err
+	}
+	result := &MesheryResult{}
+	err := json.Unmarshal(rec.Result, result)
+	if err != nil {
+		return err
+	}
+	// the results are stored under the key of the record, the id of the result being nil for those
+	// whose key was given by the remote provider; only the older archives do not have the key
+	key := rec.ID
+	if key == uuid.Nil {
+		key = result.ID
+	}
+	if key == uuid.Nil {
+		return errors.New("the result does not have an id")
+	}
+	data := []byte(rec.Result)
+	if result.ID != key {
+		result.ID = key
+		if data, err = json.Marshal(result); err != nil {
+			return err
+		}
+	}
+	createdAt := rec.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = result.StartTime()
+	}
+	imported, err := s.ImportResult(key, createdAt, data)
+	if err != nil {
+		return err
+	}
+	if imported {
+		summary.Imported++
+	} else {
+		summary.Skipped++
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ResultsFilter - represents which results are listed and in which order, the search is matched
+// case insensitively against the name, the mesh, the URL and the tags of the results
+type ResultsFilter struct {
+	Search string
+	Mesh   string
+	URL    string
+	From   time.Time
+	To     time.Time
+	// Tags - tags the results all have
+	Tags []string
+
+	OrderBy string
+	Desc    bool
+
+	// Cursor - time index key of the last result of the previous page, when the results are listed from the newest one
+	Cursor []byte
+}
+
+// results are listed from the most recently created one by default
+const defaultResultsOrder = "created_at"
+
+// resultsOrderFields are the fields results can be ordered by, as named by the columns of the UI
+var resultsOrderFields = map[string]func(a, b *resultIndexEntry) bool{
+	// the time index keys are ordered from the newest result
+	"created_at":      func(a, b *resultIndexEntry) bool { return bytes.Compare(a.key, b.key) > 0 },
+	"name":            func(a, b *resultIndexEntry) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) },
+	"mesh":            func(a, b *resultIndexEntry) bool { return strings.ToLower(a.Mesh) < strings.ToLower(b.Mesh) },
+	"url":             func(a, b *resultIndexEntry) bool { return a.URL < b.URL },
+	"test_start_time": func(a, b *resultIndexEntry) bool { return a.StartTime.Before(b.StartTime) },
+	"duration":        func(a, b *resultIndexEntry) bool { return a.Duration < b.Duration },
+	"qps":             func(a, b *resultIndexEntry) bool { return a.QPS < b.QPS },
+	"p50":             func(a, b *resultIndexEntry) bool { return a.P50Ms < b.P50Ms },
+	"p90":             func(a, b *resultIndexEntry) bool { return a.P90Ms < b.P90Ms },
+	"p99":             func(a, b *resultIndexEntry) bool { return a.P99Ms < b.P99Ms },
+	"p99_9":           func(a, b *resultIndexEntry) bool { return a.P999Ms < b.P999Ms },
+	"max":             func(a, b *resultIndexEntry) bool { return a.MaxMs < b.MaxMs },
+}
+
+// ResultsFilterError - returned for a listing of the local results whose filter is invalid
+type ResultsFilterError struct {
+	Err error
+}
+
+// Error - describes what is invalid in the filter
+func (e *ResultsFilterError) Error() string {
+	return "invalid results filter: " + e.Err.Error()
+}
+
+// ParseResultsFilter - builds the filter of a results listing from its search, its order like "p99 desc" and
+// the mesh, url, tag, from, to and cursor query parameters of the request, the dates being RFC3339 times or days
+// like 2006-01-02 and the cursor the next_cursor of the previous page
+func ParseResultsFilter(req *http.Request, search, order string) (*ResultsFilter, error) {
+	f := &ResultsFilter{
+		Search:  strings.TrimSpace(search),
+		OrderBy: defaultResultsOrder,
+		Desc:    true,
+	}
+	if req != nil {
+		q := req.URL.Query()
+		f.Mesh = strings.TrimSpace(q.Get("mesh"))
+		f.URL = strings.TrimSpace(q.Get("url"))
+		for _, t := range q["tag"] {
+			if t = strings.TrimSpace(t); t != "" {
+				f.Tags = append(f.Tags, t)
+			}
+		}
+		var err error
+		if f.From, err = parseResultsDate(q.Get("from"), false); err != nil {
+			return nil, err
+		}
+		if f.To, err = parseResultsDate(q.Get("to"), true); err != nil {
+			return nil, err
+		}
+		if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+			return nil, fmt.Errorf("the end of the date range is before its start")
+		}
+		if cursor := q.Get("cursor"); cursor != "" {
+			if f.Cursor, err = decodeResultsCursor(cursor); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fields := strings.Fields(strings.ToLower(order))
+	if len(fields) > 2 {
+		return nil, fmt.Errorf("invalid order: %s", order)
+	}
+	if len(fields) > 0 {
+		f.OrderBy = fields[0]
+		if f.OrderBy == "start_time" {
+			f.OrderBy = "test_start_time"
+		}
+		if _, ok := resultsOrderFields[f.OrderBy]; !ok {
+			return nil, fmt.Errorf("results can not be ordered by %s", fields[0])
+		}
+		f.Desc = false
+	}
+	if len(fields) > 1 {
+		switch fields[1] {
+		case "asc":
+		case "desc":
+			f.Desc = true
+		default:
+			return nil, fmt.Errorf("invalid order direction: %s", fields[1])
+		}
+	}
+	if f.Cursor != nil && !f.newestFirst() {
+		return nil, fmt.Errorf("cursors are only supported when the results are listed from the newest one")
+	}
+	return f, nil
+}
+
+// newestFirst tells whether the results are listed in the order of the time index
+func (f *ResultsFilter) newestFirst() bool {
+	return f.OrderBy == defaultResultsOrder && f.Desc
+}
+
+// all tells whether all the results are listed
+func (f *ResultsFilter) all() bool {
+	return f.Search == "" && f.Mesh == "" && f.URL == "" && len(f.Tags) == 0 && f.From.IsZero() && f.To.IsZero()
+}
+
+// parseResultsDate parses a bound of the date range, a day ending at its last instant when it is the end of the range
+func parseResultsDate(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s", s)
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// matches tells whether the result of the index entry is listed
+func (f *ResultsFilter) matches(e *resultIndexEntry) bool {
+	if f.Search != "" {
+		s := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(e.Name), s) && !strings.Contains(strings.ToLower(e.Mesh), s) &&
+			!strings.Contains(strings.ToLower(e.URL), s) && !hasTag(e.Tags, s, true) {
+			return false
+		}
+	}
+	for _, t := range f.Tags {
+		if !hasTag(e.Tags, t, false) {
+			return false
+		}
+	}
+	if f.Mesh != "" && !strings.EqualFold(f.Mesh, e.Mesh) {
+		return false
+	}
+	if f.URL != "" && !strings.Contains(strings.ToLower(e.URL), strings.ToLower(f.URL)) {
+		return false
+	}
+	if !f.From.IsZero() && e.StartTime.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && e.StartTime.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// hasTag tells whether one of the tags is the given one, or contains it when partial is set, case insensitively
+func hasTag(tags []string, tag string, partial bool) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) || (partial && strings.Contains(strings.ToLower(t), strings.ToLower(tag))) {
+			return true
+		}
+	}
+	return false
+}
+
+// sort orders the index entries, the most recently created results coming first among the equal ones
+func (f *ResultsFilter) sort(entries []*resultIndexEntry) {
+	less, ok := resultsOrderFields[f.OrderBy]
+	if !ok {
+		less = resultsOrderFields[defaultResultsOrder]
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if f.Desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		// stable between calls
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+}
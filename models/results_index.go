@@ -0,0 +1,306 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"fortio.org/fortio/stats"
+	"github.com/gofrs/uuid"
+)
+
+// resultsTimeIndexPrefix is the prefix of the keys of the time index of the results, a key is followed by the
+// inverted creation time of the result and its id so that the keys are ordered from the newest result
+var resultsTimeIndexPrefix = []byte("rt:")
+
+// resultIndexEntry - represents the fields of a result which are searched and ordered by, persisted as the value
+// of its time index key, latencies are in milliseconds
+type resultIndexEntry struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Name      string        `json:"name,omitempty"`
+	Mesh      string        `json:"mesh,omitempty"`
+	URL       string        `json:"url,omitempty"`
+	StartTime time.Time     `json:"start_time"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	QPS       float64       `json:"qps,omitempty"`
+	P50Ms     float64       `json:"p50_ms,omitempty"`
+	P90Ms     float64       `json:"p90_ms,omitempty"`
+	P99Ms     float64       `json:"p99_ms,omitempty"`
+	P999Ms    float64       `json:"p99_9_ms,omitempty"`
+	MaxMs     float64       `json:"max_ms,omitempty"`
+	Tags      []string      `json:"tags,omitempty"`
+	// Size - size of the record of the result in bytes
+	Size int64 `json:"size,omitempty"`
+
+	key []byte
+}
+
+// newResultIndexEntry reads the indexed fields of a persisted result
+func newResultIndexEntry(id uuid.UUID, createdAt time.Time, data []byte) (*resultIndexEntry, error) {
+	result := struct {
+		Name   string   `json:"name"`
+		Mesh   string   `json:"mesh"`
+		Tags   []string `json:"tags"`
+		Result struct {
+			URL               string
+			Destination       string
+			StartTime         time.Time
+			ActualDuration    time.Duration
+			ActualQPS         float64
+			DurationHistogram *stats.HistogramData
+		} `json:"runner_results"`
+	}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	r := result.Result
+	e := &resultIndexEntry{
+		ID:        id,
+		CreatedAt: createdAt,
+		Name:      result.Name,
+		Mesh:      result.Mesh,
+		Tags:      result.Tags,
+		URL:       r.URL,
+		StartTime: r.StartTime,
+		Duration:  r.ActualDuration,
+		QPS:       r.ActualQPS,
+		Size:      int64(len(data)),
+	}
+	// gRPC results have a destination instead
+	if e.URL == "" {
+		e.URL = r.Destination
+	}
+	if h := r.DurationHistogram; h != nil {
+		e.P50Ms = PercentileMs(h, 50)
+		e.P90Ms = PercentileMs(h, 90)
+		e.P99Ms = PercentileMs(h, 99)
+		e.P999Ms = PercentileMs(h, 99.9)
+		e.MaxMs = h.Max * 1000
+	}
+	e.key = resultIndexKey(e.CreatedAt, id)
+	return e, nil
+}
+
+// parseResultIndexEntry reads an entry persisted under its time index key
+func parseResultIndexEntry(key, data []byte) (*resultIndexEntry, error) {
+	if len(key) != len(resultsTimeIndexPrefix)+8+uuid.Size {
+		return nil, fmt.Errorf("invalid index key %x", key)
+	}
+	e := &resultIndexEntry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+	e.key = append([]byte{}, key...)
+	return e, nil
+}
+
+// resultIndexKey returns the time index key of a result, the results without a creation time coming last
+func resultIndexKey(createdAt time.Time, id uuid.UUID) []byte {
+	var nanos int64
+	if !createdAt.IsZero() && createdAt.UnixNano() > 0 {
+		nanos = createdAt.UnixNano()
+	}
+	key := make([]byte, 0, len(resultsTimeIndexPrefix)+8+uuid.Size)
+	key = append(key, resultsTimeIndexPrefix...)
+	key = append(key, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(key[len(resultsTimeIndexPrefix):], uint64(math.MaxInt64-nanos))
+	return append(key, id.Bytes()...)
+}
+
+// encodeResultsCursor returns the cursor of the page following the entry
+func encodeResultsCursor(e *resultIndexEntry) string {
+	return base64.RawURLEncoding.EncodeToString(e.key[len(resultsTimeIndexPrefix):])
+}
+
+// decodeResultsCursor returns the time index key a cursor stands for
+func decodeResultsCursor(cursor string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) != 8+uuid.Size {
+		return nil, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return append(append([]byte{}, resultsTimeIndexPrefix...), b...), nil
+}
+
+// resultsIndex - holds the index entries of the results by id and from the newest one
+type resultsIndex struct {
+	sync.RWMutex
+	byID    map[uuid.UUID]*resultIndexEntry
+	ordered []*resultIndexEntry
+}
+
+func newResultsIndex(entries []*resultIndexEntry) *resultsIndex {
+	x := &resultsIndex{
+		byID:    make(map[uuid.UUID]*resultIndexEntry, len(entries)),
+		ordered: make([]*resultIndexEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		x.byID[e.ID] = e
+		x.ordered = append(x.ordered, e)
+	}
+	sort.Slice(x.ordered, func(i, j int) bool {
+		return bytes.Compare(x.ordered[i].key, x.ordered[j].key) < 0
+	})
+	return x
+}
+
+// get returns the entry of the result, nil when it is not indexed
+func (x *resultsIndex) get(id uuid.UUID) *resultIndexEntry {
+	x.RLock()
+	defer x.RUnlock()
+	return x.byID[id]
+}
+
+// put adds or replaces the entry of a result
+func (x *resultsIndex) put(e *resultIndexEntry) {
+	x.Lock()
+	defer x.Unlock()
+	if old, ok := x.byID[e.ID]; ok {
+		x.removeOrdered(old)
+	}
+	x.byID[e.ID] = e
+	i := x.search(e.key)
+	x.ordered = append(x.ordered, nil)
+	copy(x.ordered[i+1:], x.ordered[i:])
+	x.ordered[i] = e
+}
+
+// remove removes the entry of a result and returns it, nil when it is not indexed
+func (x *resultsIndex) remove(id uuid.UUID) *resultIndexEntry {
+	x.Lock()
+	defer x.Unlock()
+	e, ok := x.byID[id]
+	if !ok {
+		return nil
+	}
+	delete(x.byID, id)
+	x.removeOrdered(e)
+	return e
+}
+
+func (x *resultsIndex) removeOrdered(e *resultIndexEntry) {
+	i := x.search(e.key)
+	if i < len(x.ordered) && x.ordered[i] == e {
+		x.ordered = append(x.ordered[:i], x.ordered[i+1:]...)
+	}
+}
+
+// search returns the position of the first entry whose key is not before the given one
+func (x *resultsIndex) search(key []byte) int {
+	return sort.Search(len(x.ordered), func(i int) bool {
+		return bytes.Compare(x.ordered[i].key, key) >= 0
+	})
+}
+
+// len returns the number of indexed results
+func (x *resultsIndex) len() int {
+	x.RLock()
+	defer x.RUnlock()
+	return len(x.ordered)
+}
+
+// resultsIndexPage - represents the ids of a page of results, the total count of the matching results
+// and the cursor of the next page, empty when it is the last one or the order does not have cursors
+type resultsIndexPage struct {
+	ids        []uuid.UUID
+	total      int
+	nextCursor string
+}
+
+// page returns the page of the results matching the filter, a page follows the cursor of the filter when
+// it has one, listing the newest results first only costs the size of the page
+func (x *resultsIndex) page(page, pageSize uint64, f *ResultsFilter) (*resultsIndexPage, error) {
+	x.RLock()
+	defer x.RUnlock()
+
+	if !f.newestFirst() {
+		return x.sortedPage(page, pageSize, f)
+	}
+	first := 0
+	if f.Cursor != nil {
+		first = sort.Search(len(x.ordered), func(i int) bool {
+			return bytes.Compare(x.ordered[i].key, f.Cursor) > 0
+		})
+	}
+
+	p := &resultsIndexPage{}
+	var entries []*resultIndexEntry
+	if f.all() {
+		p.total = len(x.ordered)
+		if f.Cursor == nil {
+			if page*pageSize > uint64(p.total) {
+				return nil, fmt.Errorf("index out of range")
+			}
+			first = int(page * pageSize)
+		}
+		last := first + int(pageSize)
+		if last > len(x.ordered) {
+			last = len(x.ordered)
+		}
+		entries = x.ordered[first:last]
+		if last < len(x.ordered) && len(entries) > 0 {
+			p.nextCursor = encodeResultsCursor(entries[len(entries)-1])
+		}
+	} else {
+		// only the entries in memory are gone through to count the matching results
+		skip := 0
+		if f.Cursor == nil {
+			skip = int(page * pageSize)
+		}
+		for i, e := range x.ordered {
+			if !f.matches(e) {
+				continue
+			}
+			p.total++
+			if i < first {
+				continue
+			}
+			switch {
+			case skip > 0:
+				skip--
+			case len(entries) < int(pageSize):
+				entries = append(entries, e)
+			case p.nextCursor == "" && len(entries) > 0:
+				p.nextCursor = encodeResultsCursor(entries[len(entries)-1])
+			}
+		}
+		if skip > 0 {
+			return nil, fmt.Errorf("index out of range")
+		}
+	}
+	for _, e := range entries {
+		p.ids = append(p.ids, e.ID)
+	}
+	return p, nil
+}
+
+// sortedPage returns a page of the results in another order than from the newest one
+func (x *resultsIndex) sortedPage(page, pageSize uint64, f *ResultsFilter) (*resultsIndexPage, error) {
+	entries := make([]*resultIndexEntry, 0, len(x.ordered))
+	for _, e := range x.ordered {
+		if f.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	total := len(entries)
+	start := page * pageSize
+	if start > uint64(total) {
+		return nil, fmt.Errorf("index out of range")
+	}
+	end := start + pageSize
+	if end > uint64(total) {
+		end = uint64(total)
+	}
+	f.sort(entries)
+	p := &resultsIndexPage{total: total}
+	for _, e := range entries[start:end] {
+		p.ids = append(p.ids, e.ID)
+	}
+	return p, nil
+}
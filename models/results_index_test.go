@@ -0,0 +1,187 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/prologic/bitcask"
+)
+
+// oldLayoutResult is a result as stored before the time index, only under its id
+type oldLayoutResult struct {
+	id    uuid.UUID
+	start time.Time
+	mesh  string
+}
+
+// writeOldLayoutStore writes the results without their time index, in another order than they were created
+func writeOldLayoutStore(t *testing.T, dir string, results []*oldLayoutResult) {
+	db, err := bitcask.Open(path.Join(dir, "resultDB"), bitcask.WithSync(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	for _, i := range interleaved(len(results)) {
+		r := results[i]
+		runnerResults := map[string]interface{}{"URL": "http://productpage:9080"}
+		if !r.start.IsZero() {
+			runnerResults["StartTime"] = r.start
+		}
+		data, err := json.Marshal(map[string]interface{}{
+			"meshery_id":     r.id,
+			"name":           r.id.String(),
+			"mesh":           r.mesh,
+			"runner_results": runnerResults,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Put(r.id.Bytes(), data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// interleaved returns the indexes of n elements in a fixed order which is neither the ascending nor the descending one
+func interleaved(n int) []int {
+	idx := make([]int, 0, n)
+	for i := 0; i < n; i += 2 {
+		idx = append(idx, i)
+	}
+	for i := n - 1 - n%2; i > 0; i -= 2 {
+		idx = append(idx, i)
+	}
+	return idx
+}
+
+// walkResults lists all the results matching the mesh following the cursors of the pages
+func walkResults(t *testing.T, s *BitCaskResultsPersister, mesh string, pageSize uint64) []uuid.UUID {
+	ids := []uuid.UUID{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("the cursors do not come to an end")
+		}
+		q := url.Values{}
+		if mesh != "" {
+			q.Set("mesh", mesh)
+		}
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		f, err := ParseResultsFilter(httptest.NewRequest("GET", "/api/perf/results?"+q.Encode(), nil), "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := s.GetResults(0, pageSize, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := &MesheryResultPage{}
+		if err := json.Unmarshal(data, p); err != nil {
+			t.Fatal(err)
+		}
+		if uint64(len(p.Results)) > pageSize {
+			t.Fatalf("page of %d results, expected at most %d", len(p.Results), pageSize)
+		}
+		for _, r := range p.Results {
+			ids = append(ids, r.ID)
+		}
+		if p.NextCursor == "" {
+			if len(ids) != p.TotalCount {
+				t.Errorf("listed %d results, the total count is %d", len(ids), p.TotalCount)
+			}
+			return ids
+		}
+		cursor = p.NextCursor
+	}
+}
+
+func TestResultsIndexMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meshery-results-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	base := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	results := []*oldLayoutResult{}
+	for i := 0; i < 23; i++ {
+		id, _ := uuid.NewV4()
+		r := &oldLayoutResult{id: id, start: base.Add(time.Duration(i) * time.Minute), mesh: "istio"}
+		if i%3 == 0 {
+			r.mesh = "linkerd"
+		}
+		// results started at the same time are ordered by their id
+		if i == 5 || i == 6 || i == 7 {
+			r.start = base.Add(5 * time.Minute)
+		}
+		// results without a start time come last
+		if i == 11 || i == 12 {
+			r.start = time.Time{}
+		}
+		results = append(results, r)
+	}
+	writeOldLayoutStore(t, dir, results)
+
+	expected := func(mesh string) []uuid.UUID {
+		sorted := []*oldLayoutResult{}
+		for _, r := range results {
+			if mesh == "" || r.mesh == mesh {
+				sorted = append(sorted, r)
+			}
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			a, b := sorted[i], sorted[j]
+			if !a.start.Equal(b.start) {
+				return a.start.After(b.start)
+			}
+			return bytes.Compare(a.id.Bytes(), b.id.Bytes()) < 0
+		})
+		ids := []uuid.UUID{}
+		for _, r := range sorted {
+			ids = append(ids, r.id)
+		}
+		return ids
+	}
+
+	// the store is reopened a second time to check that the index which was persisted by the migration is used
+	for _, reopen := range []string{"migration", "persisted index"} {
+		s, err := NewBitCaskResultsPersister(dir)
+		if err != nil {
+			t.Fatalf("%s: %v", reopen, err)
+		}
+		indexKeys := 0
+		for k := range s.db.Keys() {
+			if bytes.HasPrefix(k, resultsTimeIndexPrefix) {
+				indexKeys++
+			}
+		}
+		if indexKeys != len(results) {
+			t.Errorf("%s: %d index entries for %d results", reopen, indexKeys, len(results))
+		}
+		for _, mesh := range []string{"", "istio", "linkerd"} {
+			for _, pageSize := range []uint64{1, 4, 5, 23, 50} {
+				got, want := walkResults(t, s, mesh, pageSize), expected(mesh)
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("%s: results of mesh %q by pages of %d\n got: %v\nwant: %v", reopen, mesh, pageSize, got, want)
+				}
+			}
+		}
+		s.CloseResultPersister()
+	}
+}
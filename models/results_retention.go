@@ -0,0 +1,131 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+// ResultsRetentionPolicy - represents how long the results are kept in the local store, the limits which are
+// zero are not enforced and the tagged results are never deleted when KeepTagged is set
+type ResultsRetentionPolicy struct {
+	MaxAge       time.Duration `json:"max_age,omitempty"`
+	MaxCount     int           `json:"max_count,omitempty"`
+	MaxSizeBytes int64         `json:"max_size_bytes,omitempty"`
+	KeepTagged   bool          `json:"keep_tagged"`
+}
+
+// IsEmpty - tells whether the policy keeps all the results
+func (p *ResultsRetentionPolicy) IsEmpty() bool {
+	return p == nil || (p.MaxAge == 0 && p.MaxCount == 0 && p.MaxSizeBytes == 0)
+}
+
+// Validate - checks the limits of the policy
+func (p *ResultsRetentionPolicy) Validate() error {
+	if p.MaxAge < 0 || p.MaxCount < 0 || p.MaxSizeBytes < 0 {
+		return errors.New("the limits of the results retention must not be negative")
+	}
+	return nil
+}
+
+// exempts tells whether the policy never deletes the result of the index entry
+func (p *ResultsRetentionPolicy) exempts(e *resultIndexEntry) bool {
+	return p.KeepTagged && len(e.Tags) > 0
+}
+
+// ResultsStoreStatus - represents the state of the local results store and of its janitor
+type ResultsStoreStatus struct {
+	SizeBytes int64 `json:"size_bytes"`
+	Datafiles int   `json:"datafiles"`
+	Keys      int   `json:"keys"`
+	Results   int   `json:"results"`
+	// StaleWrites - deletions and overwrites since the last compaction, whose space the compaction reclaims
+	StaleWrites    int        `json:"stale_writes"`
+	LastCompaction *time.Time `json:"last_compaction,omitempty"`
+
+	Policy            *ResultsRetentionPolicy `json:"policy"`
+	Interval          time.Duration           `json:"interval"`
+	LastRun           *ResultsJanitorRun      `json:"last_run,omitempty"`
+	DeletedSinceStart int                     `json:"deleted_since_start"`
+}
+
+// ResultsJanitorRun - represents a pass of the janitor over the results store
+type ResultsJanitorRun struct {
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	Deleted         int       `json:"deleted"`
+	Compacted       bool      `json:"compacted"`
+	SizeBeforeBytes int64     `json:"size_before_bytes"`
+	SizeAfterBytes  int64     `json:"size_after_bytes"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// ResultsJanitorInterface defines the methods of the janitor enforcing the retention of the local results
+type ResultsJanitorInterface interface {
+	// RunOnce deletes the expired results and compacts the store now
+	RunOnce(ctx context.Context) (*ResultsJanitorRun, error)
+	Status(ctx context.Context) (*ResultsStoreStatus, error)
+}
+
+// ExpiredResults - returns the results which are older or beyond the count allowed by the policy, from the oldest one
+func (s *BitCaskResultsPersister) ExpiredResults(policy *ResultsRetentionPolicy, now time.Time) []uuid.UUID {
+	if policy.IsEmpty() {
+		return nil
+	}
+	s.index.RLock()
+	defer s.index.RUnlock()
+
+	expired := []uuid.UUID{}
+	kept := 0
+	// the entries are ordered from the newest one
+	for _, e := range s.index.ordered {
+		if policy.exempts(e) {
+			continue
+		}
+		tooOld := policy.MaxAge > 0 && !e.CreatedAt.IsZero() && now.Sub(e.CreatedAt) > policy.MaxAge
+		if tooOld || (policy.MaxCount > 0 && kept >= policy.MaxCount) {
+			expired = append(expired, e.ID)
+			continue
+		}
+		kept++
+	}
+	for i, j := 0, len(expired)-1; i < j; i, j = i+1, j-1 {
+		expired[i], expired[j] = expired[j], expired[i]
+	}
+	return expired
+}
+
+// OldestResults - returns the oldest results the policy does not exempt whose records and index entries add up
+// to the given size
+func (s *BitCaskResultsPersister) OldestResults(policy *ResultsRetentionPolicy, sizeBytes int64) []uuid.UUID {
+	s.storeLock.RLock()
+	defer s.storeLock.RUnlock()
+	s.index.RLock()
+	defer s.index.RUnlock()
+
+	oldest := []uuid.UUID{}
+	var total int64
+	for i := len(s.index.ordered) - 1; i >= 0 && total < sizeBytes; i-- {
+		e := s.index.ordered[i]
+		if policy.exempts(e) {
+			continue
+		}
+		size := e.Size
+		// the entries indexed before their size was recorded
+		if size == 0 {
+			if data, err := s.db.Get(e.ID.Bytes()); err == nil {
+				size = int64(len(data))
+			}
+		}
+		// the index entry is stored next to the result
+		if data, err := json.Marshal(e); err == nil {
+			size += int64(len(e.key) + len(data))
+		}
+		oldest = append(oldest, e.ID)
+		total += size
+	}
+	return oldest
+}
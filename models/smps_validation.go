@@ -0,0 +1,244 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SMPSError - represents a problem found in an SMPS document, the line is 0 when it is not known
+type SMPSError struct {
+	Line    int    `json:"line,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// String - formats the problem as line: field: message
+func (e *SMPSError) String() string {
+	s := e.Message
+	if e.Field != "" {
+		s = e.Field + ": " + s
+	}
+	if e.Line > 0 {
+		s = fmt.Sprintf("line %d: %s", e.Line, s)
+	}
+	return s
+}
+
+// SMPSValidationError - represents all the problems found in an SMPS document
+type SMPSValidationError struct {
+	Errors []*SMPSError `json:"errors"`
+}
+
+// Error - lists the problems, one per line
+func (e *SMPSValidationError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = err.String()
+	}
+	return "invalid SMPS document:\n" + strings.Join(lines, "\n")
+}
+
+var (
+	yamlLineRegex         = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	yamlUnknownFieldRegex = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+	httpMethodRegex       = regexp.MustCompile(`^[A-Za-z]+$`)
+)
+
+// ParseBenchmarkSpec - parses an SMPS document strictly, unknown fields being rejected, and checks that it describes
+// a load test which can be run, the problems are reported as an SMPSValidationError
+func ParseBenchmarkSpec(doc []byte) (*BenchmarkSpec, error) {
+	if len(bytes.TrimSpace(doc)) == 0 {
+		return nil, &SMPSValidationError{Errors: []*SMPSError{{Message: "the document is empty"}}}
+	}
+	b := &BenchmarkSpec{}
+	verr := &SMPSValidationError{}
+	if err := yaml.UnmarshalStrict(doc, b); err != nil {
+		terr, ok := err.(*yaml.TypeError)
+		if !ok {
+			// the document could not be read at all
+			verr.Errors = append(verr.Errors, yamlError(err.Error()))
+			return nil, verr
+		}
+		// the rest of the document is decoded and checked as well, so that all the problems are reported at once
+		for _, msg := range terr.Errors {
+			verr.Errors = append(verr.Errors, yamlError(msg))
+		}
+	}
+	for _, e := range b.validateLoadTest() {
+		e.Line = fieldLine(doc, e.Field)
+		verr.Errors = append(verr.Errors, e)
+	}
+	if len(verr.Errors) > 0 {
+		return nil, verr
+	}
+	return b, nil
+}
+
+// yamlError converts an error of the yaml parser, which has the line of the problem in its message
+func yamlError(msg string) *SMPSError {
+	e := &SMPSError{Message: msg}
+	if m := yamlLineRegex.FindStringSubmatch(msg); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+		e.Message = m[2]
+	}
+	if m := yamlUnknownFieldRegex.FindStringSubmatch(e.Message); m != nil {
+		e.Field = m[1]
+		e.Message = "unknown field"
+	}
+	return e
+}
+
+// validateLoadTest checks the values of the spec which are used to run a load test
+func (b *BenchmarkSpec) validateLoadTest() []*SMPSError {
+	errs := []*SMPSError{}
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, &SMPSError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !b.StartTime.IsZero() && !b.EndTime.IsZero() && b.EndTime.Before(b.StartTime) {
+		add("end_time", "must not be before start_time")
+	}
+	grpc := b.Client != nil && strings.EqualFold(b.Client.Protocol, "grpc")
+	switch {
+	case b.EndpointURL == "" && len(b.Endpoints) == 0:
+		add("endpoint_url", "an endpoint_url or endpoints are required")
+	case b.EndpointURL != "" && grpc:
+		if strings.ContainsAny(b.EndpointURL, " \t") {
+			add("endpoint_url", "invalid gRPC destination %q, expecting host:port", b.EndpointURL)
+		}
+	case b.EndpointURL != "":
+		if u, err := url.Parse(b.EndpointURL); err != nil || !u.IsAbs() {
+			add("endpoint_url", "invalid URL %q", b.EndpointURL)
+		}
+	}
+	for i, ep := range b.Endpoints {
+		if ep == nil {
+			add(fmt.Sprintf("endpoints[%d]", i), "empty endpoint")
+			continue
+		}
+		if err := ep.Validate(); err != nil {
+			add(fmt.Sprintf("endpoints[%d]", i), "%v", err)
+		}
+	}
+	if len(b.Stages) > 0 {
+		if err := ValidateLoadTestStages(b.Stages); err != nil {
+			add("stages", "%v", err)
+		}
+	}
+	if grpc && (len(b.Endpoints) > 0 || len(b.Stages) > 0) {
+		add("client.protocol", "scenarios and staged profiles are only supported for HTTP load tests")
+	}
+
+	if c := b.Client; c != nil {
+		switch strings.ToLower(c.Protocol) {
+		case "", "http", "https", "grpc":
+		default:
+			add("client.protocol", "unsupported protocol %q, expecting http, https or grpc", c.Protocol)
+		}
+		if c.Connections < 0 {
+			add("client.connections", "must not be negative")
+		}
+		if c.Rps < 0 {
+			add("client.rps", "must not be negative")
+		}
+		if _, err := ParseLoadTestArrival(c.Arrival); err != nil {
+			add("client.arrival", "%v, expecting constant or poisson", err)
+		}
+		if r := c.Request; r != nil {
+			if r.Method != "" && !httpMethodRegex.MatchString(r.Method) {
+				add("client.request.method", "invalid method %q", r.Method)
+			}
+			if r.Timeout < 0 {
+				add("client.request.timeout", "must not be negative")
+			}
+			if r.BasicAuth != "" && !strings.Contains(r.BasicAuth, ":") {
+				add("client.request.basic_auth", "expecting user:password")
+			}
+			for k := range r.Headers {
+				if strings.TrimSpace(k) == "" {
+					add("client.request.headers", "header with an empty name")
+				}
+			}
+		}
+		if g := c.GRPC; g != nil {
+			if !grpc {
+				add("client.grpc", "only used when the protocol is grpc")
+			}
+			if g.Streams < 0 {
+				add("client.grpc.streams", "must not be negative")
+			}
+			if g.PingDelay < 0 {
+				add("client.grpc.ping_delay", "must not be negative")
+			}
+		}
+	}
+
+	if !b.Thresholds.IsEmpty() {
+		if err := b.Thresholds.Validate(); err != nil {
+			add("thresholds", "%v", err)
+		}
+	}
+	if !b.StopConditions.IsEmpty() {
+		if grpc {
+			add("stop_conditions", "only supported for HTTP load tests")
+		} else if err := b.StopConditions.Validate(); err != nil {
+			add("stop_conditions", "%v", err)
+		}
+	}
+	return errs
+}
+
+// fieldLine finds the line of a field like client.request.method in a document written in the block style,
+// it returns 0 when the field is not found, as for the flow style
+func fieldLine(doc []byte, field string) int {
+	if field == "" {
+		return 0
+	}
+	lines := strings.Split(string(doc), "\n")
+	parentIndent := -1
+	line := 0
+	for _, key := range strings.Split(field, ".") {
+		// the line of a list is the one of its key
+		if i := strings.Index(key, "["); i >= 0 {
+			key = key[:i]
+		}
+		found := false
+		for ; line < len(lines); line++ {
+			l := lines[line]
+			// the keys of list items are indented past the dash
+			trimmed := strings.TrimLeft(strings.TrimPrefix(strings.TrimLeft(l, " "), "- "), " ")
+			indent := len(l) - len(trimmed)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if indent <= parentIndent && parentIndent >= 0 {
+				// left the parent
+				return 0
+			}
+			if strings.HasPrefix(trimmed, key+":") {
+				parentIndent = indent
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+		line++
+	}
+	return line
+}
+
+// SMPSErrors - returns the problems of an error returned while parsing or using an SMPS document
+func SMPSErrors(err error) []*SMPSError {
+	if verr, ok := err.(*SMPSValidationError); ok {
+		return verr.Errors
+	}
+	return []*SMPSError{{Message: err.Error()}}
+}
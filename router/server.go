@@ -2,8 +2,9 @@ package router
 
 import (
 	"context"
-	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/layer5io/meshery/handlers"
 	"github.com/layer5io/meshery/models"
@@ -12,7 +13,11 @@ import (
 // Router represents Meshery router
 type Router struct {
 	s    *http.ServeMux
+	host string
 	port int
+
+	// certFile, keyFile - certificate and key served over TLS when set
+	certFile, keyFile string
 }
 
 // NewRouter returns a new ServeMux with app routes.
@@ -32,10 +37,24 @@ func NewRouter(ctx context.Context, h models.HandlerInterface, port int) *Router
 	mux.Handle("/api/mesh/scan", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.InstalledMeshesHandler))))
 
 	mux.Handle("/api/load-test", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestHandler))))
+	mux.Handle("/api/load-test/jobs", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestJobsHandler))))
+	mux.Handle("/api/load-test/job", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestJobHandler))))
+	mux.Handle("/api/load-test/job/stream", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestJobStreamHandler))))
+	mux.Handle("/api/load-test/profiles", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestProfilesHandler))))
+	mux.Handle("/api/load-test/profile", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestProfileHandler))))
+	mux.Handle("/api/load-test/schedules", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestSchedulesHandler))))
+	mux.Handle("/api/load-test/schedule", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestScheduleHandler))))
 	mux.Handle("/api/load-test-smps", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestUsingSMPSHandler))))
+	mux.Handle("/api/load-test-smps/validate", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ValidateSMPSHandler))))
 	mux.Handle("/api/load-test-prefs", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestPrefencesHandler))))
+	mux.Handle("/api/load-test-certs", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestCertsHandler))))
 	mux.Handle("/api/results", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.FetchResultsHandler))))
-	mux.Handle("/api/result", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.GetResultHandler))))
+	mux.Handle("/api/result", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ResultHandler))))
+	mux.Handle("/api/results/compare", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.CompareResultsHandler))))
+	mux.Handle("/api/results/smps", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ImportSMPSResultsHandler))))
+	mux.Handle("/api/results/export", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ExportResultsHandler))))
+	mux.Handle("/api/results/import", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ImportResultsHandler))))
+	mux.Handle("/api/results/store", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ResultsStoreHandler))))
 
 	mux.Handle("/api/mesh/manage", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.MeshAdapterConfigHandler))))
 	mux.Handle("/api/mesh/ops", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.MeshOpsHandler))))
@@ -100,6 +119,22 @@ func NewRouter(ctx context.Context, h models.HandlerInterface, port int) *Router
 	}
 }
 
+// NewWorkerRouter returns a new ServeMux with the routes of a load test worker, listening on the host,
+// all the interfaces when it is empty, and over TLS when a certificate file and a key file are given.
+func NewWorkerRouter(ctx context.Context, h *handlers.LoadTestWorkerHandler, host string, port int, certFile, keyFile string) *Router {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/worker/ping", h.PingHandler)
+	mux.HandleFunc("/api/worker/run", h.RunHandler)
+
+	return &Router{
+		s:        mux,
+		host:     host,
+		port:     port,
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+}
+
 // Run starts the http server
 func (r *Router) Run() error {
 	// s := &http.Server{
@@ -111,5 +146,9 @@ func (r *Router) Run() error {
 	// 	IdleTimeout:    0, //time.Second,
 	// }
 	// return s.ListenAndServe()
-	return http.ListenAndServe(fmt.Sprintf(":%d", r.port), r.s)
+	addr := net.JoinHostPort(r.host, strconv.Itoa(r.port))
+	if r.certFile != "" {
+		return http.ListenAndServeTLS(addr, r.certFile, r.keyFile, r.s)
+	}
+	return http.ListenAndServe(addr, r.s)
 }